@@ -1,11 +1,73 @@
 package api
 
-import "github.com/gin-gonic/gin"
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"github.com/yqt/garmin-intl2cn/archive"
+	"github.com/yqt/garmin-intl2cn/config"
+	"github.com/yqt/garmin-intl2cn/sync"
+)
+
+var (
+	retryQueue  *sync.RetryQueue
+	syncHistory *sync.History
+
+	activityArchive *archive.Archive
+	importLedger    *sync.ImportLedger
+)
 
 func InitRoute(r *gin.Engine) error {
+	var err error
+	retryQueue, err = sync.NewRetryQueue(config.RetryQueueFile, config.RetryQueueMaxAttempts, config.RetryQueueBaseDelay)
+	if err != nil {
+		return err
+	}
+
+	syncHistory, err = sync.NewHistory(config.SyncHistoryFile)
+	if err != nil {
+		return err
+	}
+
+	activityArchive, err = archive.Open(config.ArchiveDir)
+	if err != nil {
+		return err
+	}
+
+	importLedger, err = sync.NewImportLedger(config.ImportLedgerFile)
+	if err != nil {
+		return err
+	}
+
+	// NOTE: invalid rules or transformers are reported at startup rather than on the first sync
+	if _, err = syncOptions(); err != nil {
+		return err
+	}
+	if config.ImportWatchInterval > 0 {
+		importOpts, err := importOptions()
+		if err != nil {
+			return err
+		}
+		go sync.WatchFolder(context.Background(), syncUserInfo(), config.ImportDir, config.ImportAccounts,
+			importLedger, config.ImportWatchInterval, importOpts...)
+	}
+
 	g := r.Group("/api")
 
 	g.GET("/sync", genSyncHandler)
+	g.GET("/backfill", genBackfillHandler)
+	g.GET("/sync/weight", genWeightSyncHandler)
+	g.GET("/sync/workouts", genWorkoutSyncHandler)
+	g.GET("/sync/courses", genCourseSyncHandler)
+	g.GET("/retry-queue", genRetryQueueListHandler)
+	g.POST("/retry-queue/:id/requeue", genRetryQueueRequeueHandler)
+	g.GET("/sync-history", genSyncHistoryListHandler)
+	g.GET("/archive", genArchiveHandler)
+	g.GET("/archive/index", genArchiveListHandler)
+	g.GET("/mirror", genMirrorHandler)
+	g.GET("/import", genImportHandler)
+	g.GET("/import/ledger", genImportLedgerListHandler)
+	g.GET("/users/:name/profile", genProfileHandler)
+	g.GET("/users/:name/wellness/:metric", genWellnessHandler)
 
 	return nil
 }
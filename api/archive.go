@@ -0,0 +1,38 @@
+package api
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/yqt/garmin-intl2cn/config"
+	"github.com/yqt/garmin-intl2cn/sync"
+	"net/http"
+	"time"
+)
+
+func genArchiveHandler(c *gin.Context) {
+	since, err := time.Parse("2006-01-02", c.Query("since"))
+	if err != nil {
+		c.PureJSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "",
+			"error":   fmt.Sprintf("invalid since, expected YYYY-MM-DD: %v", err),
+		})
+		return
+	}
+	rules, err := sync.ParseRules(config.FilterRules)
+	if err != nil {
+		syncResponse(c, false, "", err)
+		return
+	}
+
+	opts := append(accountOptions(), sync.Filter(rules...))
+	suc, msg, err := sync.ArchiveActivities(syncUserInfo(), since, activityArchive, opts...)
+	syncResponse(c, suc, msg, err)
+}
+
+func genArchiveListHandler(c *gin.Context) {
+	c.PureJSON(http.StatusOK, gin.H{
+		"success": true,
+		"entries": activityArchive.Entries(),
+	})
+}
@@ -0,0 +1,13 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+func genSyncHistoryListHandler(c *gin.Context) {
+	c.PureJSON(http.StatusOK, gin.H{
+		"success": true,
+		"entries": syncHistory.Entries(),
+	})
+}
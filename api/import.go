@@ -0,0 +1,34 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/yqt/garmin-intl2cn/config"
+	"github.com/yqt/garmin-intl2cn/sync"
+	"net/http"
+	"strings"
+)
+
+// genImportHandler uploads the new files of ImportDir to the accounts query
+// parameter, e.g. "intl,cn", or to ImportAccounts by default.
+func genImportHandler(c *gin.Context) {
+	accounts := config.ImportAccounts
+	if c.Query("accounts") != "" {
+		accounts = strings.Split(c.Query("accounts"), ",")
+	}
+
+	opts, err := importOptions()
+	if err != nil {
+		syncResponse(c, false, "", err)
+		return
+	}
+
+	suc, msg, err := sync.ImportFolder(syncUserInfo(), config.ImportDir, accounts, importLedger, opts...)
+	syncResponse(c, suc, msg, err)
+}
+
+func genImportLedgerListHandler(c *gin.Context) {
+	c.PureJSON(http.StatusOK, gin.H{
+		"success": true,
+		"entries": importLedger.Entries(),
+	})
+}
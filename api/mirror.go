@@ -0,0 +1,79 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/yqt/garmin-intl2cn/config"
+	"github.com/yqt/garmin-intl2cn/garmin"
+	"github.com/yqt/garmin-intl2cn/s3"
+	"github.com/yqt/garmin-intl2cn/sync"
+	"github.com/yqt/garmin-intl2cn/webdav"
+	"net/http"
+	"time"
+)
+
+// genMirrorHandler copies the activities of the intl account started since a
+// date to the target query parameter, "archive", "webdav" or "s3".
+func genMirrorHandler(c *gin.Context) {
+	since, err := time.Parse("2006-01-02", c.Query("since"))
+	if err != nil {
+		c.PureJSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "",
+			"error":   fmt.Sprintf("invalid since, expected YYYY-MM-DD: %v", err),
+		})
+		return
+	}
+	target, err := mirrorTarget(c.Query("target"))
+	if err != nil {
+		c.PureJSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "",
+			"error":   err.Error(),
+		})
+		return
+	}
+	rules, err := sync.ParseRules(config.FilterRules)
+	if err != nil {
+		syncResponse(c, false, "", err)
+		return
+	}
+
+	client, _ := accountClient(sync.AccountIntl)
+	filter := garmin.ActivityFilter{StartDate: since}
+	suc, msg, err := sync.Mirror(context.Background(), sync.NewGarminSource(client), target, filter,
+		sync.Workers(config.SyncWorkers), sync.Filter(rules...))
+	syncResponse(c, suc, msg, err)
+}
+
+func mirrorTarget(name string) (sync.Target, error) {
+	switch name {
+	case "archive":
+		return sync.NewArchiveTarget(activityArchive), nil
+	case "webdav":
+		if config.WebDAVURL == "" {
+			return nil, fmt.Errorf("webdav target is not configured")
+		}
+		return sync.NewStorageTarget(webdav.NewClient(config.WebDAVURL,
+			webdav.BasicAuth(config.WebDAVUsername, config.WebDAVPassword))), nil
+	case "s3":
+		if config.S3Endpoint == "" || config.S3Bucket == "" {
+			return nil, fmt.Errorf("s3 target is not configured")
+		}
+		opts := make([]s3.Option, 0)
+		if config.S3ForbiddenAsMissing {
+			opts = append(opts, s3.ForbiddenAsMissing())
+		}
+		return sync.NewStorageTarget(s3.NewClient(s3.Config{
+			Endpoint:        config.S3Endpoint,
+			Region:          config.S3Region,
+			Bucket:          config.S3Bucket,
+			AccessKeyId:     config.S3AccessKeyId,
+			SecretAccessKey: config.S3SecretAccessKey,
+			Prefix:          config.S3Prefix,
+			PathStyle:       config.S3PathStyle,
+		}, opts...)), nil
+	}
+	return nil, fmt.Errorf("unknown mirror target: %s", name)
+}
@@ -0,0 +1,46 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/yqt/garmin-intl2cn/sync"
+	"net/http"
+	"strconv"
+)
+
+func genRetryQueueListHandler(c *gin.Context) {
+	c.PureJSON(http.StatusOK, gin.H{
+		"success": true,
+		"entries": retryQueue.Entries(),
+	})
+}
+
+func genRetryQueueRequeueHandler(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.PureJSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	err = retryQueue.Requeue(id)
+	if err == sync.ErrRetryEntryNotFound {
+		c.PureJSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.PureJSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.PureJSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
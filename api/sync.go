@@ -7,11 +7,75 @@ import (
 	"github.com/yqt/garmin-intl2cn/config"
 	"github.com/yqt/garmin-intl2cn/garmin"
 	"github.com/yqt/garmin-intl2cn/sync"
+	"github.com/yqt/garmin-intl2cn/util"
 	"net/http"
+	"time"
 )
 
 func genSyncHandler(c *gin.Context) {
-	userInfo := sync.UserInfo{
+	opts, err := syncOptions()
+	if err != nil {
+		syncResponse(c, false, "", err)
+		return
+	}
+
+	suc, msg, err := sync.SynchronizeLatestActivities(syncUserInfo(), opts...)
+	syncResponse(c, suc, msg, err)
+}
+
+func genBackfillHandler(c *gin.Context) {
+	since, err := time.Parse("2006-01-02", c.Query("since"))
+	if err != nil {
+		c.PureJSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "",
+			"error":   fmt.Sprintf("invalid since, expected YYYY-MM-DD: %v", err),
+		})
+		return
+	}
+	opts, err := syncOptions()
+	if err != nil {
+		syncResponse(c, false, "", err)
+		return
+	}
+
+	suc, msg, err := sync.BackfillActivities(syncUserInfo(), since, opts...)
+	syncResponse(c, suc, msg, err)
+}
+
+func genWeightSyncHandler(c *gin.Context) {
+	since := time.Now().AddDate(0, 0, -config.WeightSyncDays)
+	if c.Query("since") != "" {
+		var err error
+		since, err = time.Parse("2006-01-02", c.Query("since"))
+		if err != nil {
+			c.PureJSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "",
+				"error":   fmt.Sprintf("invalid since, expected YYYY-MM-DD: %v", err),
+			})
+			return
+		}
+	}
+
+	suc, msg, err := sync.SynchronizeWeights(syncUserInfo(), since, accountOptions()...)
+	syncResponse(c, suc, msg, err)
+}
+
+func genWorkoutSyncHandler(c *gin.Context) {
+	opts := append(accountOptions(), sync.SyncHistory(syncHistory))
+	suc, msg, err := sync.SynchronizeWorkouts(syncUserInfo(), config.WorkoutScheduleDays, opts...)
+	syncResponse(c, suc, msg, err)
+}
+
+func genCourseSyncHandler(c *gin.Context) {
+	opts := append(accountOptions(), sync.SyncHistory(syncHistory))
+	suc, msg, err := sync.SynchronizeCourses(syncUserInfo(), opts...)
+	syncResponse(c, suc, msg, err)
+}
+
+func syncUserInfo() sync.UserInfo {
+	return sync.UserInfo{
 		Intl: garmin.UserInfo{
 			Email:    config.GarminEmail,
 			Password: config.GarminPassword,
@@ -21,8 +85,78 @@ func genSyncHandler(c *gin.Context) {
 			Password: config.GarminCnPassword,
 		},
 	}
-	suc, msg, err := sync.SynchronizeLatestActivities(userInfo)
+}
+
+func syncOptions() ([]sync.Option, error) {
+	rules, err := sync.ParseRules(config.FilterRules)
+	if err != nil {
+		return nil, err
+	}
+
+	transformers, err := newTransformers()
+	if err != nil {
+		return nil, err
+	}
+
+	opts := append(accountOptions(),
+		sync.Workers(config.SyncWorkers),
+		sync.Queue(retryQueue),
+		sync.Filter(rules...),
+		sync.Transformers(transformers...),
+		sync.SyncHistory(syncHistory),
+	)
+	if config.LinkGear {
+		opts = append(opts, sync.LinkGear())
+	}
+	return opts, nil
+}
+
+// importOptions apply the sync transformers to the files imported to cn.
+func importOptions() ([]sync.Option, error) {
+	transformers, err := newTransformers()
+	if err != nil {
+		return nil, err
+	}
+	return append(accountOptions(), sync.Transformers(transformers...)), nil
+}
+
+func newTransformers() ([]sync.Transformer, error) {
+	return sync.NewTransformers(config.Transformers, sync.TransformerSettings{
+		PrivacyZones: config.PrivacyZones,
+		PrivacyMode:  config.PrivacyMode,
+		Conversion:   config.CoordinateConversion,
+		NameTemplate: config.ActivityNameTemplate,
+	})
+}
+
+// accountOptions are shared by every sync between the intl and cn accounts.
+func accountOptions() []sync.Option {
+	opts := []sync.Option{
+		sync.ClientOptions(clientOptions()...),
+	}
+	if config.ValidateSameOwner {
+		opts = append(opts, sync.ValidateSameOwner())
+	}
+	return opts
+}
+
+func clientOptions() []garmin.Option {
+	return []garmin.Option{
+		garmin.RateLimit(util.RateLimit{
+			RequestsPerMinute: config.RequestsPerMinute,
+			Burst:             config.RequestBurst,
+			MinDelay:          config.RequestMinDelay,
+			MaxDelay:          config.RequestMaxDelay,
+		}),
+		garmin.Retry(util.RetryPolicy{
+			MaxAttempts: config.RetryMaxAttempts,
+			BaseDelay:   config.RetryBaseDelay,
+			MaxDelay:    config.RetryMaxDelay,
+		}),
+	}
+}
 
+func syncResponse(c *gin.Context, suc bool, msg string, err error) {
 	logrus.WithFields(logrus.Fields{
 		"suc": suc,
 		"msg": msg,
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/yqt/garmin-intl2cn/garmin"
+	"github.com/yqt/garmin-intl2cn/sync"
+	"net/http"
+)
+
+// genProfileHandler returns the profile and devices of the intl or cn account.
+func genProfileHandler(c *gin.Context) {
+	client, ok := accountClient(c.Param("name"))
+	if !ok {
+		c.PureJSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   fmt.Sprintf("unknown user: %s, expected intl or cn", c.Param("name")),
+		})
+		return
+	}
+
+	var (
+		profile garmin.Profile
+		devices []garmin.Device
+	)
+	err := client.Auth(false)
+	if err == nil {
+		profile, err = client.Profile()
+	}
+	if err == nil {
+		devices, err = client.Devices()
+	}
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"user": c.Param("name"),
+			"err":  err,
+		}).Error("profile request failed")
+		c.PureJSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.PureJSON(http.StatusOK, gin.H{
+		"success": true,
+		"profile": profile,
+		"devices": devices,
+	})
+}
+
+func accountClient(name string) (*garmin.Client, bool) {
+	userInfo := syncUserInfo()
+	switch name {
+	case sync.AccountIntl:
+		return garmin.NewClient(append([]garmin.Option{
+			garmin.Credentials(userInfo.Intl.Email, userInfo.Intl.Password),
+			garmin.SetEnv(garmin.ApiServiceHost, garmin.SsoPrefix),
+		}, clientOptions()...)...), true
+	case sync.AccountCn:
+		return garmin.NewClient(append([]garmin.Option{
+			garmin.Credentials(userInfo.Cn.Email, userInfo.Cn.Password),
+			garmin.SetEnv(garmin.ApiServiceHostCn, garmin.SsoPrefixCn),
+		}, clientOptions()...)...), true
+	}
+	return nil, false
+}
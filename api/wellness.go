@@ -0,0 +1,113 @@
+package api
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/yqt/garmin-intl2cn/config"
+	"github.com/yqt/garmin-intl2cn/garmin"
+	"net/http"
+	"time"
+)
+
+type wellnessFetcher func(client *garmin.Client, start time.Time, end time.Time) (interface{}, error)
+
+var wellnessFetchers = map[string]wellnessFetcher{
+	"summary": func(client *garmin.Client, start time.Time, end time.Time) (interface{}, error) {
+		return client.GetDailySummaries(start, end)
+	},
+	"sleep": func(client *garmin.Client, start time.Time, end time.Time) (interface{}, error) {
+		return client.GetSleep(start, end)
+	},
+	"hrv": func(client *garmin.Client, start time.Time, end time.Time) (interface{}, error) {
+		return client.GetHRV(start, end)
+	},
+	"stress": func(client *garmin.Client, start time.Time, end time.Time) (interface{}, error) {
+		return client.GetStress(start, end)
+	},
+	"body-battery": func(client *garmin.Client, start time.Time, end time.Time) (interface{}, error) {
+		return client.GetBodyBattery(start, end)
+	},
+	"resting-hr": func(client *garmin.Client, start time.Time, end time.Time) (interface{}, error) {
+		return client.GetRestingHeartRate(start, end)
+	},
+	"steps": func(client *garmin.Client, start time.Time, end time.Time) (interface{}, error) {
+		return client.GetSteps(start, end)
+	},
+}
+
+// genWellnessHandler returns the wellness data of the intl or cn account between
+// the start and end query dates, the last 7 days by default.
+func genWellnessHandler(c *gin.Context) {
+	fetch, ok := wellnessFetchers[c.Param("metric")]
+	if !ok {
+		c.PureJSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   fmt.Sprintf("unknown wellness metric: %s", c.Param("metric")),
+		})
+		return
+	}
+	client, ok := accountClient(c.Param("name"))
+	if !ok {
+		c.PureJSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   fmt.Sprintf("unknown user: %s, expected intl or cn", c.Param("name")),
+		})
+		return
+	}
+	start, end, err := wellnessRange(c.Query("start"), c.Query("end"), time.Now())
+	if err != nil {
+		c.PureJSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if err = client.Auth(false); err == nil {
+		var data interface{}
+		data, err = fetch(client, start, end)
+		if err == nil {
+			c.PureJSON(http.StatusOK, gin.H{
+				"success": true,
+				"data":    data,
+			})
+			return
+		}
+	}
+	logrus.WithFields(logrus.Fields{
+		"user":   c.Param("name"),
+		"metric": c.Param("metric"),
+		"err":    err,
+	}).Error("wellness request failed")
+	c.PureJSON(http.StatusInternalServerError, gin.H{
+		"success": false,
+		"error":   err.Error(),
+	})
+}
+
+// wellnessRange parses the YYYY-MM-DD start and end of a wellness request, both optional.
+func wellnessRange(startText string, endText string, now time.Time) (time.Time, time.Time, error) {
+	end := now
+	if endText != "" {
+		var err error
+		if end, err = time.Parse("2006-01-02", endText); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid end, expected YYYY-MM-DD: %v", err)
+		}
+	}
+	start := end.AddDate(0, 0, -6)
+	if startText != "" {
+		var err error
+		if start, err = time.Parse("2006-01-02", startText); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid start, expected YYYY-MM-DD: %v", err)
+		}
+	}
+
+	if start.After(end) {
+		return time.Time{}, time.Time{}, fmt.Errorf("start %s is after end %s", start.Format("2006-01-02"), end.Format("2006-01-02"))
+	}
+	if end.Sub(start) >= time.Duration(config.WellnessMaxDays)*24*time.Hour {
+		return time.Time{}, time.Time{}, fmt.Errorf("range exceeds %d days", config.WellnessMaxDays)
+	}
+	return start, end, nil
+}
@@ -0,0 +1,304 @@
+package archive
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/yqt/garmin-intl2cn/garmin"
+	"github.com/yqt/garmin-intl2cn/util"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+const (
+	IndexFileName = "index.json"
+	// maxNameLength bounds the activity name part of archived file names, in runes
+	maxNameLength = 48
+)
+
+var ErrNotArchived = errors.New("activity not archived")
+
+// Entry is an archived activity. Paths are relative to the archive root and use
+// forward slashes, so an archive can be moved between systems.
+type Entry struct {
+	ActivityId     int64     `json:"activityId"`
+	ActivityName   string    `json:"activityName"`
+	ActivityType   string    `json:"activityType"`
+	StartTimeLocal string    `json:"startTimeLocal"`
+	StartTimeGMT   string    `json:"startTimeGMT"`
+	Metadata       string    `json:"metadata"`
+	Files          []string  `json:"files"`
+	ArchivedAt     time.Time `json:"archivedAt"`
+}
+
+// Archive keeps the original files and the metadata of activities in a local
+// directory tree, laid out as year/month/id_name.ext, with an index of every
+// archived activity at its root.
+type Archive struct {
+	root string
+
+	mu      sync.Mutex
+	entries map[int64]*Entry
+	now     func() time.Time
+}
+
+// Open loads the archive at root, creating the directory if needed.
+func Open(root string) (*Archive, error) {
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, err
+	}
+	a := &Archive{
+		root:    root,
+		entries: make(map[int64]*Entry),
+		now:     time.Now,
+	}
+
+	entries := make([]*Entry, 0)
+	if err := util.ReadJSONFile(filepath.Join(root, IndexFileName), &entries); err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		a.entries[entry.ActivityId] = entry
+	}
+	return a, nil
+}
+
+func (a *Archive) Root() string {
+	return a.root
+}
+
+// Has reports whether the activity is archived with all of its files.
+func (a *Archive) Has(activityId int64) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	entry, ok := a.entries[activityId]
+	return ok && a.complete(entry)
+}
+
+// Store archives the files of an activity along with its metadata. Activities
+// already archived are left untouched and reported with stored false. The files
+// of an incomplete previous store are replaced, even if the activity was renamed.
+func (a *Archive) Store(activity garmin.Activity, files []garmin.ActivityFile) (Entry, bool, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	previous, ok := a.entries[activity.ActivityId]
+	if ok && a.complete(previous) {
+		return *previous, false, nil
+	}
+
+	dir := activityDir(activity)
+	if err := os.MkdirAll(filepath.Join(a.root, filepath.FromSlash(dir)), 0755); err != nil {
+		return Entry{}, false, err
+	}
+	base := path.Join(dir, baseName(activity))
+
+	entry := &Entry{
+		ActivityId:     activity.ActivityId,
+		ActivityName:   activity.ActivityName,
+		ActivityType:   activity.ActivityType.TypeKey,
+		StartTimeLocal: activity.Summary.StartTimeLocal,
+		StartTimeGMT:   activity.Summary.StartTimeGMT,
+		Metadata:       base + ".json",
+		Files:          make([]string, 0, len(files)),
+		ArchivedAt:     a.now(),
+	}
+	for i, file := range files {
+		name := base
+		if len(files) > 1 {
+			name += "_" + strconv.Itoa(i+1)
+		}
+		name += FileExt(file)
+		if err := util.WriteFileAtomic(a.path(name), file.Data); err != nil {
+			return Entry{}, false, err
+		}
+		entry.Files = append(entry.Files, name)
+	}
+
+	if err := util.WriteJSONFile(a.path(entry.Metadata), activity); err != nil {
+		return Entry{}, false, err
+	}
+
+	// NOTE: the index is written last, so an interrupted store is simply redone
+	a.entries[activity.ActivityId] = entry
+	if err := a.save(); err != nil {
+		return Entry{}, false, err
+	}
+	if previous != nil {
+		a.removeStale(previous, entry)
+	}
+	return *entry, true, nil
+}
+
+// removeStale deletes the files of previous which entry does not use anymore.
+// Failures only leave orphaned files behind, so they are ignored.
+func (a *Archive) removeStale(previous *Entry, entry *Entry) {
+	used := make(map[string]bool)
+	for _, name := range append([]string{entry.Metadata}, entry.Files...) {
+		used[name] = true
+	}
+	for _, name := range append([]string{previous.Metadata}, previous.Files...) {
+		if !used[name] {
+			_ = os.Remove(a.path(name))
+		}
+	}
+}
+
+// Entries returns the archived activities, oldest first.
+func (a *Archive) Entries() []Entry {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	entries := make([]Entry, 0, len(a.entries))
+	for _, entry := range a.sortedEntries() {
+		entries = append(entries, *entry)
+	}
+	return entries
+}
+
+// Activity returns the archived metadata of an activity.
+func (a *Archive) Activity(activityId int64) (garmin.Activity, error) {
+	entry, err := a.entry(activityId)
+	if err != nil {
+		return garmin.Activity{}, err
+	}
+	content, err := ioutil.ReadFile(a.path(entry.Metadata))
+	if err != nil {
+		return garmin.Activity{}, err
+	}
+	activity := garmin.Activity{}
+	err = json.Unmarshal(content, &activity)
+	return activity, err
+}
+
+// Files returns the archived files of an activity, ready to be uploaded again.
+func (a *Archive) Files(activityId int64) ([]garmin.ActivityFile, error) {
+	entry, err := a.entry(activityId)
+	if err != nil {
+		return nil, err
+	}
+	files := make([]garmin.ActivityFile, 0, len(entry.Files))
+	for _, name := range entry.Files {
+		data, err := ioutil.ReadFile(a.path(name))
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, garmin.NewActivityFile(path.Base(name), data))
+	}
+	return files, nil
+}
+
+func (a *Archive) entry(activityId int64) (Entry, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	entry, ok := a.entries[activityId]
+	if !ok {
+		return Entry{}, fmt.Errorf("%w: %d", ErrNotArchived, activityId)
+	}
+	return *entry, nil
+}
+
+func (a *Archive) complete(entry *Entry) bool {
+	for _, name := range append([]string{entry.Metadata}, entry.Files...) {
+		if _, err := os.Stat(a.path(name)); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+func (a *Archive) path(name string) string {
+	return filepath.Join(a.root, filepath.FromSlash(name))
+}
+
+func (a *Archive) sortedEntries() []*Entry {
+	entries := make([]*Entry, 0, len(a.entries))
+	for _, entry := range a.entries {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].StartTimeGMT != entries[j].StartTimeGMT {
+			return entries[i].StartTimeGMT < entries[j].StartTimeGMT
+		}
+		return entries[i].ActivityId < entries[j].ActivityId
+	})
+	return entries
+}
+
+func (a *Archive) save() error {
+	return util.WriteJSONFile(filepath.Join(a.root, IndexFileName), a.sortedEntries())
+}
+
+// ActivityKey is the year/month/id path of an activity, for stores which key
+// activities by id only. startTimeLocal is as in the activity or its list item.
+func ActivityKey(activityId int64, startTimeLocal string) string {
+	return startMonth(startTimeLocal) + "/" + strconv.FormatInt(activityId, 10)
+}
+
+// activityDir is the year/month the activity started in, local to where it was recorded.
+func activityDir(activity garmin.Activity) string {
+	return startMonth(activity.Summary.StartTimeLocal)
+}
+
+func startMonth(startTimeLocal string) string {
+	for _, layout := range []string{"2006-01-02T15:04:05", "2006-01-02 15:04:05"} {
+		startTime := startTimeLocal
+		if len(startTime) > len(layout) {
+			startTime = startTime[:len(layout)]
+		}
+		if t, err := time.Parse(layout, startTime); err == nil {
+			return t.Format("2006/01")
+		}
+	}
+	return "unknown"
+}
+
+// baseName is the id followed by the activity name, with anything but letters
+// and digits replaced, so names are safe on every file system.
+func baseName(activity garmin.Activity) string {
+	var b strings.Builder
+	count := 0
+	lastUnderscore := true
+	for _, r := range activity.ActivityName {
+		if count >= maxNameLength {
+			break
+		}
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			lastUnderscore = false
+		} else if !lastUnderscore {
+			b.WriteRune('_')
+			lastUnderscore = true
+		}
+		count++
+	}
+	name := strings.TrimSuffix(b.String(), "_")
+
+	id := strconv.FormatInt(activity.ActivityId, 10)
+	if name == "" {
+		return id
+	}
+	return id + "_" + name
+}
+
+// FileExt is the extension an archived file is stored with, from its format.
+func FileExt(file garmin.ActivityFile) string {
+	format := file.Format
+	if format == "" || format == garmin.FormatOriginal {
+		format = garmin.DetectFormat(file.FileName, file.Data)
+	}
+	if format == "" {
+		return path.Ext(file.FileName)
+	}
+	return "." + string(format)
+}
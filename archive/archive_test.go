@@ -0,0 +1,103 @@
+package archive
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/yqt/garmin-intl2cn/garmin"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func testActivity() garmin.Activity {
+	return garmin.Activity{
+		ActivityId:   123456,
+		ActivityName: "Shanghai - Morning Run!",
+		ActivityType: garmin.ActivityType{TypeId: 1, TypeKey: "running"},
+		Summary: garmin.Summary{
+			StartTimeLocal: "2021-01-02T08:30:00.0",
+			StartTimeGMT:   "2021-01-02T00:30:00.0",
+		},
+	}
+}
+
+func TestArchive_Store(t *testing.T) {
+	root, err := ioutil.TempDir("", "archive")
+	assert.Nil(t, err)
+	defer os.RemoveAll(root)
+
+	a, err := Open(root)
+	assert.Nil(t, err)
+	assert.False(t, a.Has(123456))
+
+	files := []garmin.ActivityFile{garmin.NewActivityFile("123456_ACTIVITY.fit", []byte("fit data"))}
+	entry, stored, err := a.Store(testActivity(), files)
+	assert.Nil(t, err)
+	assert.True(t, stored)
+	assert.Equal(t, "2021/01/123456_Shanghai_Morning_Run.json", entry.Metadata)
+	assert.Equal(t, []string{"2021/01/123456_Shanghai_Morning_Run.fit"}, entry.Files)
+	assert.True(t, a.Has(123456))
+
+	_, stored, err = a.Store(testActivity(), files)
+	assert.Nil(t, err)
+	assert.False(t, stored)
+
+	// the index is read back by a later open
+	a, err = Open(root)
+	assert.Nil(t, err)
+	assert.Len(t, a.Entries(), 1)
+
+	activity, err := a.Activity(123456)
+	assert.Nil(t, err)
+	assert.Equal(t, "running", activity.ActivityType.TypeKey)
+	archived, err := a.Files(123456)
+	assert.Nil(t, err)
+	assert.Equal(t, garmin.FormatFIT, archived[0].Format)
+	assert.Equal(t, []byte("fit data"), archived[0].Data)
+
+	// a missing file is archived again
+	assert.Nil(t, os.Remove(filepath.Join(root, "2021", "01", "123456_Shanghai_Morning_Run.fit")))
+	assert.False(t, a.Has(123456))
+	_, stored, err = a.Store(testActivity(), files)
+	assert.Nil(t, err)
+	assert.True(t, stored)
+
+	// an activity renamed since replaces the files of the incomplete store
+	assert.Nil(t, os.Remove(filepath.Join(root, "2021", "01", "123456_Shanghai_Morning_Run.fit")))
+	renamed := testActivity()
+	renamed.ActivityName = "Evening Run"
+	entry, stored, err = a.Store(renamed, files)
+	assert.Nil(t, err)
+	assert.True(t, stored)
+	assert.Equal(t, []string{"2021/01/123456_Evening_Run.fit"}, entry.Files)
+	_, err = os.Stat(filepath.Join(root, "2021", "01", "123456_Shanghai_Morning_Run.json"))
+	assert.True(t, os.IsNotExist(err))
+	names, err := ioutil.ReadDir(filepath.Join(root, "2021", "01"))
+	assert.Nil(t, err)
+	assert.Len(t, names, 2)
+
+	_, err = a.Files(654321)
+	assert.ErrorIs(t, err, ErrNotArchived)
+}
+
+func TestBaseName(t *testing.T) {
+	activity := testActivity()
+	assert.Equal(t, "123456_Shanghai_Morning_Run", baseName(activity))
+
+	activity.ActivityName = "上海 跑步"
+	assert.Equal(t, "123456_上海_跑步", baseName(activity))
+
+	activity.ActivityName = "../"
+	assert.Equal(t, "123456", baseName(activity))
+}
+
+func TestActivityDir(t *testing.T) {
+	assert.Equal(t, "2021/01", activityDir(testActivity()))
+
+	activity := testActivity()
+	activity.Summary.StartTimeLocal = "2020-12-31 23:59:59"
+	assert.Equal(t, "2020/12", activityDir(activity))
+
+	activity.Summary.StartTimeLocal = ""
+	assert.Equal(t, "unknown", activityDir(activity))
+}
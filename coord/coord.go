@@ -0,0 +1,76 @@
+// Package coord converts coordinates between WGS-84, used by GPS devices, and
+// GCJ-02, the obfuscated datum required for maps in mainland China.
+package coord
+
+import "math"
+
+const (
+	// Krasovsky 1940 ellipsoid used by GCJ-02
+	semiMajorAxis = 6378245.0
+	eccentricity2 = 0.00669342162296594323
+
+	// GCJ02ToWGS84 stops once the round trip is within this many degrees (about 1cm)
+	precision = 1e-7
+)
+
+// OutOfChina reports whether a point lies outside the rough bounding box of China,
+// where GCJ-02 equals WGS-84.
+func OutOfChina(lat float64, lng float64) bool {
+	return lng < 72.004 || lng > 137.8347 || lat < 0.8293 || lat > 55.8271
+}
+
+func WGS84ToGCJ02(lat float64, lng float64) (float64, float64) {
+	if OutOfChina(lat, lng) {
+		return lat, lng
+	}
+	dLat, dLng := delta(lat, lng)
+	return lat + dLat, lng + dLng
+}
+
+// GCJ02ToWGS84 inverts WGS84ToGCJ02 iteratively, since the offset has no closed form inverse.
+func GCJ02ToWGS84(lat float64, lng float64) (float64, float64) {
+	if OutOfChina(lat, lng) {
+		return lat, lng
+	}
+	wgsLat, wgsLng := lat, lng
+	for i := 0; i < 30; i++ {
+		gcjLat, gcjLng := WGS84ToGCJ02(wgsLat, wgsLng)
+		dLat, dLng := gcjLat-lat, gcjLng-lng
+		if math.Abs(dLat) < precision && math.Abs(dLng) < precision {
+			break
+		}
+		wgsLat -= dLat
+		wgsLng -= dLng
+	}
+	return wgsLat, wgsLng
+}
+
+func delta(lat float64, lng float64) (float64, float64) {
+	dLat := transformLat(lng-105.0, lat-35.0)
+	dLng := transformLng(lng-105.0, lat-35.0)
+
+	radLat := lat / 180.0 * math.Pi
+	magic := math.Sin(radLat)
+	magic = 1 - eccentricity2*magic*magic
+	sqrtMagic := math.Sqrt(magic)
+
+	dLat = (dLat * 180.0) / ((semiMajorAxis * (1 - eccentricity2)) / (magic * sqrtMagic) * math.Pi)
+	dLng = (dLng * 180.0) / (semiMajorAxis / sqrtMagic * math.Cos(radLat) * math.Pi)
+	return dLat, dLng
+}
+
+func transformLat(x float64, y float64) float64 {
+	ret := -100.0 + 2.0*x + 3.0*y + 0.2*y*y + 0.1*x*y + 0.2*math.Sqrt(math.Abs(x))
+	ret += (20.0*math.Sin(6.0*x*math.Pi) + 20.0*math.Sin(2.0*x*math.Pi)) * 2.0 / 3.0
+	ret += (20.0*math.Sin(y*math.Pi) + 40.0*math.Sin(y/3.0*math.Pi)) * 2.0 / 3.0
+	ret += (160.0*math.Sin(y/12.0*math.Pi) + 320*math.Sin(y*math.Pi/30.0)) * 2.0 / 3.0
+	return ret
+}
+
+func transformLng(x float64, y float64) float64 {
+	ret := 300.0 + x + 2.0*y + 0.1*x*x + 0.1*x*y + 0.1*math.Sqrt(math.Abs(x))
+	ret += (20.0*math.Sin(6.0*x*math.Pi) + 20.0*math.Sin(2.0*x*math.Pi)) * 2.0 / 3.0
+	ret += (20.0*math.Sin(x*math.Pi) + 40.0*math.Sin(x/3.0*math.Pi)) * 2.0 / 3.0
+	ret += (150.0*math.Sin(x/12.0*math.Pi) + 300.0*math.Sin(x/30.0*math.Pi)) * 2.0 / 3.0
+	return ret
+}
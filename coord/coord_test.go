@@ -0,0 +1,49 @@
+package coord
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestWGS84ToGCJ02(t *testing.T) {
+	lat, lng := WGS84ToGCJ02(31.1774276, 121.5272106)
+	assert.InDelta(t, 31.17530398364597, lat, 1e-9)
+	assert.InDelta(t, 121.531541859215, lng, 1e-9)
+}
+
+func TestGCJ02ToWGS84(t *testing.T) {
+	lat, lng := GCJ02ToWGS84(31.17530398364597, 121.531541859215)
+	assert.InDelta(t, 31.1774276, lat, precision)
+	assert.InDelta(t, 121.5272106, lng, precision)
+}
+
+func TestRoundTrip(t *testing.T) {
+	points := [][2]float64{
+		{39.9092428, 116.3979702},
+		{22.5436494, 113.9378738},
+		{30.5728, 104.0668},
+		{43.8256, 87.6168},
+	}
+	for _, p := range points {
+		gcjLat, gcjLng := WGS84ToGCJ02(p[0], p[1])
+		// the offset is a few hundred meters everywhere in China
+		assert.True(t, gcjLat != p[0] && gcjLng != p[1])
+		assert.InDelta(t, p[0], gcjLat, 0.01)
+		assert.InDelta(t, p[1], gcjLng, 0.01)
+
+		lat, lng := GCJ02ToWGS84(gcjLat, gcjLng)
+		assert.InDelta(t, p[0], lat, precision)
+		assert.InDelta(t, p[1], lng, precision)
+	}
+}
+
+func TestOutOfChina(t *testing.T) {
+	// Paris
+	lat, lng := WGS84ToGCJ02(48.8566, 2.3522)
+	assert.Equal(t, 48.8566, lat)
+	assert.Equal(t, 2.3522, lng)
+
+	lat, lng = GCJ02ToWGS84(48.8566, 2.3522)
+	assert.Equal(t, 48.8566, lat)
+	assert.Equal(t, 2.3522, lng)
+}
@@ -0,0 +1,273 @@
+package fit
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+type BaseType byte
+
+const (
+	BaseTypeEnum    BaseType = 0x00
+	BaseTypeSint8   BaseType = 0x01
+	BaseTypeUint8   BaseType = 0x02
+	BaseTypeSint16  BaseType = 0x83
+	BaseTypeUint16  BaseType = 0x84
+	BaseTypeSint32  BaseType = 0x85
+	BaseTypeUint32  BaseType = 0x86
+	BaseTypeString  BaseType = 0x07
+	BaseTypeFloat32 BaseType = 0x88
+	BaseTypeFloat64 BaseType = 0x89
+	BaseTypeUint8z  BaseType = 0x0A
+	BaseTypeUint16z BaseType = 0x8B
+	BaseTypeUint32z BaseType = 0x8C
+	BaseTypeByte    BaseType = 0x0D
+	BaseTypeSint64  BaseType = 0x8E
+	BaseTypeUint64  BaseType = 0x8F
+	BaseTypeUint64z BaseType = 0x90
+)
+
+// baseTypeSizes also tells which base types are known.
+var baseTypeSizes = map[BaseType]int{
+	BaseTypeEnum:    1,
+	BaseTypeSint8:   1,
+	BaseTypeUint8:   1,
+	BaseTypeSint16:  2,
+	BaseTypeUint16:  2,
+	BaseTypeSint32:  4,
+	BaseTypeUint32:  4,
+	BaseTypeString:  1,
+	BaseTypeFloat32: 4,
+	BaseTypeFloat64: 8,
+	BaseTypeUint8z:  1,
+	BaseTypeUint16z: 2,
+	BaseTypeUint32z: 4,
+	BaseTypeByte:    1,
+	BaseTypeSint64:  8,
+	BaseTypeUint64:  8,
+	BaseTypeUint64z: 8,
+}
+
+// normalizeBaseType maps base type ids written without the endian ability bit
+// (e.g. 0x04 for uint16) to their canonical value.
+func normalizeBaseType(id byte) BaseType {
+	for baseType := range baseTypeSizes {
+		if byte(baseType)&0x1F == id&0x1F {
+			return baseType
+		}
+	}
+	return BaseType(id)
+}
+
+func (t BaseType) Size() int {
+	if size, ok := baseTypeSizes[t]; ok {
+		return size
+	}
+	return 1
+}
+
+// decodeValue turns the raw bytes of a field into the Go value documented on Field.
+// Fields whose size does not fit their base type are kept as raw bytes.
+func decodeValue(baseType BaseType, data []byte, order binary.ByteOrder) (BaseType, interface{}) {
+	size := baseType.Size()
+	if _, ok := baseTypeSizes[baseType]; !ok || len(data)%size != 0 {
+		return BaseTypeByte, append([]byte(nil), data...)
+	}
+	count := len(data) / size
+
+	switch baseType {
+	case BaseTypeString:
+		end := 0
+		for end < len(data) && data[end] != 0 {
+			end++
+		}
+		return baseType, string(data[:end])
+	case BaseTypeByte:
+		return baseType, append([]byte(nil), data...)
+	case BaseTypeEnum, BaseTypeUint8, BaseTypeUint8z:
+		if count == 1 {
+			return baseType, data[0]
+		}
+		return baseType, append([]byte(nil), data...)
+	case BaseTypeSint8:
+		values := make([]int8, count)
+		for i := range values {
+			values[i] = int8(data[i])
+		}
+		if count == 1 {
+			return baseType, values[0]
+		}
+		return baseType, values
+	case BaseTypeSint16:
+		values := make([]int16, count)
+		for i := range values {
+			values[i] = int16(order.Uint16(data[i*2:]))
+		}
+		if count == 1 {
+			return baseType, values[0]
+		}
+		return baseType, values
+	case BaseTypeUint16, BaseTypeUint16z:
+		values := make([]uint16, count)
+		for i := range values {
+			values[i] = order.Uint16(data[i*2:])
+		}
+		if count == 1 {
+			return baseType, values[0]
+		}
+		return baseType, values
+	case BaseTypeSint32:
+		values := make([]int32, count)
+		for i := range values {
+			values[i] = int32(order.Uint32(data[i*4:]))
+		}
+		if count == 1 {
+			return baseType, values[0]
+		}
+		return baseType, values
+	case BaseTypeUint32, BaseTypeUint32z:
+		values := make([]uint32, count)
+		for i := range values {
+			values[i] = order.Uint32(data[i*4:])
+		}
+		if count == 1 {
+			return baseType, values[0]
+		}
+		return baseType, values
+	case BaseTypeFloat32:
+		values := make([]float32, count)
+		for i := range values {
+			values[i] = math.Float32frombits(order.Uint32(data[i*4:]))
+		}
+		if count == 1 {
+			return baseType, values[0]
+		}
+		return baseType, values
+	case BaseTypeFloat64:
+		values := make([]float64, count)
+		for i := range values {
+			values[i] = math.Float64frombits(order.Uint64(data[i*8:]))
+		}
+		if count == 1 {
+			return baseType, values[0]
+		}
+		return baseType, values
+	case BaseTypeSint64:
+		values := make([]int64, count)
+		for i := range values {
+			values[i] = int64(order.Uint64(data[i*8:]))
+		}
+		if count == 1 {
+			return baseType, values[0]
+		}
+		return baseType, values
+	case BaseTypeUint64, BaseTypeUint64z:
+		values := make([]uint64, count)
+		for i := range values {
+			values[i] = order.Uint64(data[i*8:])
+		}
+		if count == 1 {
+			return baseType, values[0]
+		}
+		return baseType, values
+	}
+	return BaseTypeByte, append([]byte(nil), data...)
+}
+
+func isValidValue(baseType BaseType, value interface{}) bool {
+	switch v := value.(type) {
+	case string:
+		return v != ""
+	case uint8:
+		return isValidScalar(baseType, uint64(v))
+	case int8:
+		return v != math.MaxInt8
+	case uint16:
+		return isValidScalar(baseType, uint64(v))
+	case int16:
+		return v != math.MaxInt16
+	case uint32:
+		return isValidScalar(baseType, uint64(v))
+	case int32:
+		return v != math.MaxInt32
+	case uint64:
+		return isValidScalar(baseType, v)
+	case int64:
+		return v != math.MaxInt64
+	case float32:
+		return math.Float32bits(v) != math.MaxUint32
+	case float64:
+		return math.Float64bits(v) != math.MaxUint64
+	case []byte:
+		for _, e := range v {
+			if isValidScalar(baseType, uint64(e)) {
+				return true
+			}
+		}
+	case []int8:
+		for _, e := range v {
+			if e != math.MaxInt8 {
+				return true
+			}
+		}
+	case []uint16:
+		for _, e := range v {
+			if isValidScalar(baseType, uint64(e)) {
+				return true
+			}
+		}
+	case []int16:
+		for _, e := range v {
+			if e != math.MaxInt16 {
+				return true
+			}
+		}
+	case []uint32:
+		for _, e := range v {
+			if isValidScalar(baseType, uint64(e)) {
+				return true
+			}
+		}
+	case []int32:
+		for _, e := range v {
+			if e != math.MaxInt32 {
+				return true
+			}
+		}
+	case []uint64:
+		for _, e := range v {
+			if isValidScalar(baseType, e) {
+				return true
+			}
+		}
+	case []int64:
+		for _, e := range v {
+			if e != math.MaxInt64 {
+				return true
+			}
+		}
+	case []float32:
+		for _, e := range v {
+			if math.Float32bits(e) != math.MaxUint32 {
+				return true
+			}
+		}
+	case []float64:
+		for _, e := range v {
+			if math.Float64bits(e) != math.MaxUint64 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func isValidScalar(baseType BaseType, v uint64) bool {
+	switch baseType {
+	case BaseTypeUint8z, BaseTypeUint16z, BaseTypeUint32z, BaseTypeUint64z:
+		return v != 0
+	}
+	// NOTE: for 8 byte types the shift overflows to 0 and the subtraction wraps to MaxUint64
+	size := uint(baseType.Size())
+	return v != (uint64(1)<<(size*8))-1
+}
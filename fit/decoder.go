@@ -0,0 +1,273 @@
+package fit
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+type fieldDefinition struct {
+	num      byte
+	size     byte
+	baseType BaseType
+}
+
+type developerFieldDefinition struct {
+	num                byte
+	size               byte
+	developerDataIndex byte
+}
+
+type definition struct {
+	num             MesgNum
+	order           binary.ByteOrder
+	fields          []fieldDefinition
+	developerFields []developerFieldDefinition
+}
+
+type developerFieldKey struct {
+	developerDataIndex byte
+	num                byte
+}
+
+type decoder struct {
+	data []byte
+	pos  int
+
+	definitions    [16]*definition
+	developerTypes map[developerFieldKey]BaseType
+	lastTimestamp  uint32
+}
+
+// Decode reads a FIT file and checks both the header and the file CRC.
+func Decode(r io.Reader) (*File, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return DecodeBytes(data)
+}
+
+func DecodeBytes(data []byte) (*File, error) {
+	header, err := decodeHeader(data)
+	if err != nil {
+		return nil, err
+	}
+
+	end := int(header.Size) + int(header.DataSize)
+	if len(data) < end+2 {
+		return nil, ErrTruncated
+	}
+	file := &File{
+		Header: header,
+		CRC:    binary.LittleEndian.Uint16(data[end:]),
+	}
+	if CRC(0, data[:end]) != file.CRC {
+		return nil, ErrInvalidCRC
+	}
+
+	d := &decoder{
+		data:           data[:end],
+		pos:            int(header.Size),
+		developerTypes: make(map[developerFieldKey]BaseType),
+	}
+	for d.pos < len(d.data) {
+		msg, err := d.next()
+		if err != nil {
+			return nil, err
+		}
+		if msg != nil {
+			file.Messages = append(file.Messages, *msg)
+		}
+	}
+
+	return file, nil
+}
+
+func decodeHeader(data []byte) (Header, error) {
+	if len(data) < legacyHeaderSize {
+		return Header{}, ErrNotFIT
+	}
+	header := Header{
+		Size:            data[0],
+		ProtocolVersion: data[1],
+		ProfileVersion:  binary.LittleEndian.Uint16(data[2:]),
+		DataSize:        binary.LittleEndian.Uint32(data[4:]),
+	}
+	if header.Size != legacyHeaderSize && header.Size != headerSize || string(data[8:12]) != ".FIT" {
+		return Header{}, ErrNotFIT
+	}
+	if header.Size == headerSize {
+		if len(data) < headerSize {
+			return Header{}, ErrTruncated
+		}
+		header.CRC = binary.LittleEndian.Uint16(data[12:])
+		// NOTE: a header CRC of 0 means it was not computed
+		if header.CRC != 0 && CRC(0, data[:12]) != header.CRC {
+			return Header{}, ErrInvalidCRC
+		}
+	}
+	return header, nil
+}
+
+func (d *decoder) read(n int) ([]byte, error) {
+	if d.pos+n > len(d.data) {
+		return nil, ErrTruncated
+	}
+	b := d.data[d.pos : d.pos+n]
+	d.pos += n
+	return b, nil
+}
+
+// next reads one record and returns the data message it holds, or nil for a definition.
+func (d *decoder) next() (*Message, error) {
+	b, err := d.read(1)
+	if err != nil {
+		return nil, err
+	}
+	recordHeader := b[0]
+
+	if recordHeader&0x80 != 0 {
+		localNum := (recordHeader >> 5) & 0x03
+		offset := uint32(recordHeader & 0x1F)
+		timestamp := (d.lastTimestamp &^ 0x1F) + offset
+		if offset < d.lastTimestamp&0x1F {
+			timestamp += 0x20
+		}
+		msg, err := d.readData(localNum)
+		if err != nil {
+			return nil, err
+		}
+		msg.Fields = append([]Field{{Num: FieldNumTimestamp, Type: BaseTypeUint32, Value: timestamp}}, msg.Fields...)
+		d.lastTimestamp = timestamp
+		return msg, nil
+	}
+
+	localNum := recordHeader & 0x0F
+	if recordHeader&0x40 != 0 {
+		return nil, d.readDefinition(localNum, recordHeader&0x20 != 0)
+	}
+
+	msg, err := d.readData(localNum)
+	if err != nil {
+		return nil, err
+	}
+	if timestamp, ok := messageTimestamp(msg); ok {
+		d.lastTimestamp = timestamp
+	}
+	if msg.Num == MesgNumFieldDescription {
+		d.registerFieldDescription(msg)
+	}
+	return msg, nil
+}
+
+func (d *decoder) readDefinition(localNum byte, hasDeveloperFields bool) error {
+	b, err := d.read(5)
+	if err != nil {
+		return err
+	}
+	def := &definition{
+		order: binary.LittleEndian,
+	}
+	if b[1] == 1 {
+		def.order = binary.BigEndian
+	}
+	def.num = MesgNum(def.order.Uint16(b[2:]))
+
+	fieldCount := int(b[4])
+	b, err = d.read(fieldCount * 3)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < fieldCount; i++ {
+		def.fields = append(def.fields, fieldDefinition{
+			num:      b[i*3],
+			size:     b[i*3+1],
+			baseType: normalizeBaseType(b[i*3+2]),
+		})
+	}
+
+	if hasDeveloperFields {
+		b, err = d.read(1)
+		if err != nil {
+			return err
+		}
+		fieldCount = int(b[0])
+		b, err = d.read(fieldCount * 3)
+		if err != nil {
+			return err
+		}
+		for i := 0; i < fieldCount; i++ {
+			def.developerFields = append(def.developerFields, developerFieldDefinition{
+				num:                b[i*3],
+				size:               b[i*3+1],
+				developerDataIndex: b[i*3+2],
+			})
+		}
+	}
+
+	d.definitions[localNum] = def
+	return nil
+}
+
+func (d *decoder) readData(localNum byte) (*Message, error) {
+	def := d.definitions[localNum]
+	if def == nil {
+		return nil, fmt.Errorf("%w: local message type %d at offset %d", ErrUndefinedMsg, localNum, d.pos-1)
+	}
+
+	msg := &Message{
+		Num:      def.num,
+		LocalNum: localNum,
+		Fields:   make([]Field, 0, len(def.fields)),
+	}
+	for _, fieldDef := range def.fields {
+		b, err := d.read(int(fieldDef.size))
+		if err != nil {
+			return nil, err
+		}
+		baseType, value := decodeValue(fieldDef.baseType, b, def.order)
+		msg.Fields = append(msg.Fields, Field{
+			Num:   fieldDef.num,
+			Type:  baseType,
+			Value: value,
+		})
+	}
+	for _, fieldDef := range def.developerFields {
+		b, err := d.read(int(fieldDef.size))
+		if err != nil {
+			return nil, err
+		}
+		baseType, ok := d.developerTypes[developerFieldKey{fieldDef.developerDataIndex, fieldDef.num}]
+		if !ok {
+			baseType = BaseTypeByte
+		}
+		baseType, value := decodeValue(baseType, b, def.order)
+		msg.DeveloperFields = append(msg.DeveloperFields, DeveloperField{
+			Num:                fieldDef.num,
+			DeveloperDataIndex: fieldDef.developerDataIndex,
+			Type:               baseType,
+			Value:              value,
+		})
+	}
+
+	return msg, nil
+}
+
+func (d *decoder) registerFieldDescription(msg *Message) {
+	description := newFieldDescription(msg)
+	d.developerTypes[developerFieldKey{description.DeveloperDataIndex, description.FieldDefinitionNumber}] = description.BaseType
+}
+
+// messageTimestamp returns the timestamp field of msg, which compressed timestamps
+// of the following messages are relative to. As in the FIT SDK, invalid values
+// count as well, so the encoder and the decoder have to agree on this rule.
+func messageTimestamp(msg *Message) (uint32, bool) {
+	if f := msg.Field(FieldNumTimestamp); f != nil {
+		if timestamp, ok := f.Value.(uint32); ok {
+			return timestamp, true
+		}
+	}
+	return 0, false
+}
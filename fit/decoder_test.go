@@ -0,0 +1,256 @@
+package fit
+
+import (
+	"encoding/binary"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+// testdata/activity.fit is a 5 second run holding little and big endian definitions,
+// a developer field, compressed timestamp records and an invalid heart rate.
+func readFixture(t *testing.T) []byte {
+	data, err := ioutil.ReadFile("testdata/activity.fit")
+	assert.Nil(t, err)
+	return data
+}
+
+func decodeFixture(t *testing.T) *File {
+	file, err := os.Open("testdata/activity.fit")
+	assert.Nil(t, err)
+	defer file.Close()
+
+	fitFile, err := Decode(file)
+	assert.Nil(t, err)
+	return fitFile
+}
+
+var fixtureStart = time.Date(2021, 9, 8, 1, 46, 40, 0, time.UTC)
+
+func TestDecode_Header(t *testing.T) {
+	fitFile := decodeFixture(t)
+
+	assert.Equal(t, byte(14), fitFile.Header.Size)
+	assert.Equal(t, byte(0x20), fitFile.Header.ProtocolVersion)
+	assert.Equal(t, uint16(2132), fitFile.Header.ProfileVersion)
+	assert.Equal(t, uint32(len(readFixture(t))-16), fitFile.Header.DataSize)
+	assert.Len(t, fitFile.Messages, 14)
+}
+
+func TestDecode_FileID(t *testing.T) {
+	fitFile := decodeFixture(t)
+
+	fileID, ok := fitFile.FileID()
+	assert.True(t, ok)
+	assert.Equal(t, FileID{
+		Type:         4,
+		Manufacturer: 1,
+		Product:      3121,
+		SerialNumber: 3999999999,
+		TimeCreated:  fixtureStart,
+	}, fileID)
+}
+
+func TestDecode_Records(t *testing.T) {
+	fitFile := decodeFixture(t)
+
+	records := fitFile.Records()
+	assert.Len(t, records, 5)
+	for i, record := range records {
+		assert.Equal(t, fixtureStart.Add(time.Duration(i)*time.Second), record.Timestamp)
+		assert.True(t, record.HasPosition)
+		assert.InDelta(t, 31.2304+float64(i)*0.0001, record.Position.Lat, 1e-6)
+		assert.InDelta(t, 121.4737+float64(i)*0.0001, record.Position.Long, 1e-6)
+		assert.InDelta(t, 12, record.Altitude, 1e-9)
+		assert.InDelta(t, float64(i)*3, record.Distance, 1e-9)
+		assert.InDelta(t, 3, record.Speed, 1e-9)
+	}
+
+	assert.Equal(t, uint8(120), records[0].HeartRate)
+	// 0xFF is the invalid value of uint8
+	assert.Equal(t, uint8(0), records[1].HeartRate)
+	assert.Equal(t, uint8(124), records[4].HeartRate)
+}
+
+func TestDecode_CompressedTimestamp(t *testing.T) {
+	fitFile := decodeFixture(t)
+
+	compressed := 0
+	for _, msg := range fitFile.Messages {
+		if msg.Num == MesgNumRecord && msg.LocalNum == 0 {
+			compressed++
+			assert.Equal(t, byte(FieldNumTimestamp), msg.Fields[0].Num)
+		}
+	}
+	assert.Equal(t, 2, compressed)
+}
+
+func TestDecode_DeveloperFields(t *testing.T) {
+	fitFile := decodeFixture(t)
+
+	descriptions := fitFile.FieldDescriptions()
+	assert.Equal(t, []FieldDescription{{
+		DeveloperDataIndex:    0,
+		FieldDefinitionNumber: 0,
+		BaseType:              BaseTypeFloat32,
+		FieldName:             "Doughnuts Earned",
+		Units:                 "doughnuts",
+	}}, descriptions)
+
+	doughnuts := make([]float32, 0)
+	for _, msg := range fitFile.Messages {
+		if f := msg.DeveloperField(0, 0); f != nil {
+			doughnuts = append(doughnuts, f.Value.(float32))
+		}
+	}
+	assert.Equal(t, []float32{0, 0.5, 1}, doughnuts)
+}
+
+func TestDecode_DeviceInfo(t *testing.T) {
+	fitFile := decodeFixture(t)
+
+	// device_info is written with a big endian definition
+	assert.Equal(t, []DeviceInfo{{
+		Timestamp:       fixtureStart,
+		Manufacturer:    1,
+		SerialNumber:    3999999999,
+		Product:         3121,
+		SoftwareVersion: 12.5,
+		ProductName:     "Forerunner",
+	}}, fitFile.DeviceInfos())
+}
+
+func TestDecode_LapSessionEvent(t *testing.T) {
+	fitFile := decodeFixture(t)
+
+	laps := fitFile.Laps()
+	assert.Len(t, laps, 1)
+	assert.Equal(t, fixtureStart, laps[0].StartTime)
+	assert.True(t, laps[0].HasEndPosition)
+	assert.InDelta(t, 31.2308, laps[0].EndPosition.Lat, 1e-6)
+	assert.Equal(t, 4*time.Second, laps[0].TotalTimerTime)
+	assert.InDelta(t, 12, laps[0].TotalDistance, 1e-9)
+	assert.Equal(t, uint8(1), laps[0].Sport)
+
+	sessions := fitFile.Sessions()
+	assert.Len(t, sessions, 1)
+	assert.Equal(t, uint8(1), sessions[0].Sport)
+	assert.Equal(t, uint16(1), sessions[0].NumLaps)
+	assert.Equal(t, uint8(124), sessions[0].MaxHeartRate)
+	assert.InDelta(t, 121.4737, sessions[0].StartPosition.Long, 1e-6)
+
+	events := fitFile.Events()
+	assert.Len(t, events, 2)
+	assert.Equal(t, uint8(0), events[0].EventType)
+	assert.Equal(t, uint8(4), events[1].EventType)
+	assert.Equal(t, fixtureStart.Add(4*time.Second), events[1].Timestamp)
+}
+
+func TestDecode_InvalidCRC(t *testing.T) {
+	data := readFixture(t)
+	data[40] ^= 0xFF
+
+	_, err := DecodeBytes(data)
+	assert.Equal(t, ErrInvalidCRC, err)
+}
+
+func TestDecode_NotFIT(t *testing.T) {
+	_, err := DecodeBytes([]byte(`<?xml version="1.0" encoding="UTF-8"?><gpx></gpx>`))
+	assert.Equal(t, ErrNotFIT, err)
+}
+
+func TestDecode_Truncated(t *testing.T) {
+	data := readFixture(t)
+
+	_, err := DecodeBytes(data[:len(data)-10])
+	assert.Equal(t, ErrTruncated, err)
+}
+
+func TestCRC(t *testing.T) {
+	data := readFixture(t)
+
+	assert.Equal(t, uint16(0), CRC(0, data))
+}
+
+// testdata/device_activity.fit is laid out like a watch recording: a 14 byte header,
+// three devices, two Connect IQ apps writing developer fields and a minute of
+// records of which all but the first have a compressed timestamp.
+func decodeDeviceFixture(t *testing.T) *File {
+	data, err := ioutil.ReadFile("testdata/device_activity.fit")
+	assert.Nil(t, err)
+	fitFile, err := DecodeBytes(data)
+	assert.Nil(t, err)
+	return fitFile
+}
+
+var deviceFixtureStart = time.Date(2021, 9, 10, 16, 0, 0, 0, time.UTC)
+
+func TestDecode_DeviceFixture(t *testing.T) {
+	fitFile := decodeDeviceFixture(t)
+
+	assert.Equal(t, byte(14), fitFile.Header.Size)
+	assert.NotZero(t, fitFile.Header.CRC)
+	assert.Len(t, fitFile.DeviceInfos(), 3)
+	assert.Len(t, fitFile.Laps(), 2)
+	assert.Len(t, fitFile.Sessions(), 1)
+
+	records := fitFile.Records()
+	assert.Len(t, records, 60)
+	for i, record := range records {
+		assert.Equal(t, deviceFixtureStart.Add(time.Duration(i)*time.Second), record.Timestamp)
+	}
+	compressed := 0
+	for _, msg := range fitFile.Messages {
+		if msg.Num == MesgNumRecord && msg.LocalNum == 1 {
+			compressed++
+		}
+	}
+	assert.Equal(t, 59, compressed)
+
+	descriptions := fitFile.FieldDescriptions()
+	assert.Len(t, descriptions, 4)
+	assert.Equal(t, FieldDescription{
+		DeveloperDataIndex:    1,
+		FieldDefinitionNumber: 0,
+		BaseType:              BaseTypeUint8,
+		FieldName:             "Air Power",
+		Units:                 "%",
+	}, descriptions[2])
+
+	power := make([]uint16, 0)
+	for _, msg := range fitFile.Messages {
+		if msg.Num != MesgNumRecord {
+			continue
+		}
+		assert.Len(t, msg.DeveloperFields, 3)
+		assert.IsType(t, float32(0), msg.DeveloperField(0, 1).Value)
+		assert.IsType(t, uint8(0), msg.DeveloperField(1, 0).Value)
+		power = append(power, msg.DeveloperField(0, 0).Value.(uint16))
+	}
+	assert.Equal(t, uint16(240), power[0])
+	assert.Equal(t, uint16(259), power[19])
+}
+
+func TestDecodeValue_Byte(t *testing.T) {
+	baseType, value := decodeValue(BaseTypeByte, []byte{0x2A}, binary.LittleEndian)
+	assert.Equal(t, BaseTypeByte, baseType)
+	assert.Equal(t, []byte{0x2A}, value)
+
+	_, value = decodeValue(BaseTypeByte, []byte{0x2A, 0x2B}, binary.LittleEndian)
+	assert.Equal(t, []byte{0x2A, 0x2B}, value)
+
+	// a single uint8 is still a scalar
+	_, value = decodeValue(BaseTypeUint8, []byte{0x2A}, binary.LittleEndian)
+	assert.Equal(t, uint8(0x2A), value)
+
+	data, err := EncodeBytes(&File{Messages: []Message{{
+		Num:    MesgNumFileID,
+		Fields: []Field{{Num: 100, Type: BaseTypeByte, Value: []byte{0x2A}}},
+	}}})
+	assert.Nil(t, err)
+	decoded, err := DecodeBytes(data)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{0x2A}, decoded.Messages[0].Field(100).Value)
+}
@@ -0,0 +1,219 @@
+package fit
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+type encoder struct {
+	compressTimestamps bool
+
+	buf              *bytes.Buffer
+	definitions      [16][]byte
+	lastTimestamp    uint32
+	hasLastTimestamp bool
+}
+
+type EncoderOption func(e *encoder)
+
+// CompressTimestamps writes messages whose timestamp is less than 32 seconds
+// after the previous one with a compressed timestamp header.
+func CompressTimestamps() EncoderOption {
+	return func(e *encoder) {
+		e.compressTimestamps = true
+	}
+}
+
+// Encode writes file with little endian definitions, recalculating the data size
+// and both CRCs. Definitions are only written when a local message type changes.
+func Encode(w io.Writer, file *File, options ...EncoderOption) error {
+	data, err := EncodeBytes(file, options...)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+func EncodeBytes(file *File, options ...EncoderOption) ([]byte, error) {
+	e := &encoder{
+		buf: &bytes.Buffer{},
+	}
+	for _, option := range options {
+		option(e)
+	}
+
+	header := make([]byte, headerSize)
+	e.buf.Write(header)
+	for i := range file.Messages {
+		if err := e.writeMessage(&file.Messages[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	data := e.buf.Bytes()
+	protocolVersion := file.Header.ProtocolVersion
+	if protocolVersion == 0 {
+		protocolVersion = ProtocolVersion
+	}
+	profileVersion := file.Header.ProfileVersion
+	if profileVersion == 0 {
+		profileVersion = ProfileVersion
+	}
+	data[0] = headerSize
+	data[1] = protocolVersion
+	binary.LittleEndian.PutUint16(data[2:], profileVersion)
+	binary.LittleEndian.PutUint32(data[4:], uint32(len(data)-headerSize))
+	copy(data[8:], ".FIT")
+	binary.LittleEndian.PutUint16(data[12:], CRC(0, data[:12]))
+
+	crc := make([]byte, 2)
+	binary.LittleEndian.PutUint16(crc, CRC(0, data))
+	return append(data, crc...), nil
+}
+
+func (e *encoder) writeMessage(msg *Message) error {
+	fields := msg.Fields
+	localNum := msg.LocalNum & 0x0F
+	compressed := false
+
+	if timestamp, ok := messageTimestamp(msg); ok {
+		if e.compressTimestamps && e.hasLastTimestamp && timestamp >= e.lastTimestamp && timestamp-e.lastTimestamp < 0x20 {
+			compressed = true
+			localNum &= 0x03
+			fields = make([]Field, 0, len(msg.Fields)-1)
+			for _, f := range msg.Fields {
+				if f.Num != FieldNumTimestamp {
+					fields = append(fields, f)
+				}
+			}
+		}
+		e.lastTimestamp = timestamp
+		e.hasLastTimestamp = true
+	}
+
+	def, err := e.definition(msg, fields, localNum)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(e.definitions[localNum], def) {
+		e.buf.Write(def)
+		e.definitions[localNum] = def
+	}
+
+	if compressed {
+		e.buf.WriteByte(0x80 | localNum<<5 | byte(e.lastTimestamp&0x1F))
+	} else {
+		e.buf.WriteByte(localNum)
+	}
+	for _, f := range fields {
+		if err := writeValue(e.buf, f.Type, f.Value); err != nil {
+			return fmt.Errorf("fit: message %d field %d: %w", msg.Num, f.Num, err)
+		}
+	}
+	for _, f := range msg.DeveloperFields {
+		if err := writeValue(e.buf, f.Type, f.Value); err != nil {
+			return fmt.Errorf("fit: message %d developer field %d: %w", msg.Num, f.Num, err)
+		}
+	}
+	return nil
+}
+
+func (e *encoder) definition(msg *Message, fields []Field, localNum byte) ([]byte, error) {
+	def := &bytes.Buffer{}
+	recordHeader := 0x40 | localNum
+	if len(msg.DeveloperFields) > 0 {
+		recordHeader |= 0x20
+	}
+	def.WriteByte(recordHeader)
+	def.WriteByte(0)
+	def.WriteByte(0)
+	_ = binary.Write(def, binary.LittleEndian, uint16(msg.Num))
+
+	if len(fields) > math.MaxUint8 || len(msg.DeveloperFields) > math.MaxUint8 {
+		return nil, fmt.Errorf("fit: message %d has too many fields", msg.Num)
+	}
+	def.WriteByte(byte(len(fields)))
+	for _, f := range fields {
+		size, err := valueSize(f.Type, f.Value)
+		if err != nil {
+			return nil, fmt.Errorf("fit: message %d field %d: %w", msg.Num, f.Num, err)
+		}
+		def.Write([]byte{f.Num, size, byte(f.Type)})
+	}
+
+	if len(msg.DeveloperFields) > 0 {
+		def.WriteByte(byte(len(msg.DeveloperFields)))
+		for _, f := range msg.DeveloperFields {
+			size, err := valueSize(f.Type, f.Value)
+			if err != nil {
+				return nil, fmt.Errorf("fit: message %d developer field %d: %w", msg.Num, f.Num, err)
+			}
+			def.Write([]byte{f.Num, size, f.DeveloperDataIndex})
+		}
+	}
+	return def.Bytes(), nil
+}
+
+func valueSize(baseType BaseType, value interface{}) (byte, error) {
+	elemSize, count := 1, 1
+	switch v := value.(type) {
+	case string:
+		count = len(v) + 1
+	case uint8, int8:
+	case uint16, int16:
+		elemSize = 2
+	case uint32, int32, float32:
+		elemSize = 4
+	case uint64, int64, float64:
+		elemSize = 8
+	case []byte:
+		count = len(v)
+	case []int8:
+		count = len(v)
+	case []uint16:
+		elemSize, count = 2, len(v)
+	case []int16:
+		elemSize, count = 2, len(v)
+	case []uint32:
+		elemSize, count = 4, len(v)
+	case []int32:
+		elemSize, count = 4, len(v)
+	case []float32:
+		elemSize, count = 4, len(v)
+	case []uint64:
+		elemSize, count = 8, len(v)
+	case []int64:
+		elemSize, count = 8, len(v)
+	case []float64:
+		elemSize, count = 8, len(v)
+	default:
+		return 0, fmt.Errorf("unsupported value type %T", value)
+	}
+
+	// NOTE: raw bytes are kept for fields whose size did not fit their base type
+	if elemSize != baseType.Size() && baseType != BaseTypeByte {
+		return 0, fmt.Errorf("value type %T does not match base type %#x", value, byte(baseType))
+	}
+	// NOTE: zero-size fields are valid, they are written as empty values
+	size := elemSize * count
+	if size > math.MaxUint8 {
+		return 0, fmt.Errorf("invalid field size %d", size)
+	}
+	return byte(size), nil
+}
+
+func writeValue(buf *bytes.Buffer, baseType BaseType, value interface{}) error {
+	if _, err := valueSize(baseType, value); err != nil {
+		return err
+	}
+	if s, ok := value.(string); ok {
+		buf.WriteString(s)
+		buf.WriteByte(0)
+		return nil
+	}
+	return binary.Write(buf, binary.LittleEndian, value)
+}
@@ -0,0 +1,210 @@
+package fit
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func withoutLocalNums(messages []Message) []Message {
+	result := make([]Message, len(messages))
+	for i, msg := range messages {
+		msg.LocalNum = 0
+		result[i] = msg
+	}
+	return result
+}
+
+func TestEncode_RoundTrip(t *testing.T) {
+	decoded := decodeFixture(t)
+
+	buf := &bytes.Buffer{}
+	assert.Nil(t, Encode(buf, decoded))
+
+	reDecoded, err := Decode(buf)
+	assert.Nil(t, err)
+	assert.Equal(t, decoded.Header.ProtocolVersion, reDecoded.Header.ProtocolVersion)
+	assert.Equal(t, decoded.Header.ProfileVersion, reDecoded.Header.ProfileVersion)
+	assert.Equal(t, decoded.Messages, reDecoded.Messages)
+}
+
+func TestEncode_Stable(t *testing.T) {
+	decoded := decodeFixture(t)
+
+	first, err := EncodeBytes(decoded)
+	assert.Nil(t, err)
+	reDecoded, err := DecodeBytes(first)
+	assert.Nil(t, err)
+	second, err := EncodeBytes(reDecoded)
+	assert.Nil(t, err)
+	assert.Equal(t, first, second)
+}
+
+func TestEncode_CompressTimestamps(t *testing.T) {
+	decoded := decodeFixture(t)
+
+	plain, err := EncodeBytes(decoded)
+	assert.Nil(t, err)
+	compressed, err := EncodeBytes(decoded, CompressTimestamps())
+	assert.Nil(t, err)
+	assert.True(t, len(compressed) < len(plain))
+
+	reDecoded, err := DecodeBytes(compressed)
+	assert.Nil(t, err)
+	assert.Equal(t, withoutLocalNums(decoded.Messages), withoutLocalNums(reDecoded.Messages))
+	assert.Equal(t, decoded.Records(), reDecoded.Records())
+}
+
+func TestEncode_ModifiedMessages(t *testing.T) {
+	decoded := decodeFixture(t)
+
+	for i := range decoded.Messages {
+		msg := &decoded.Messages[i]
+		if msg.Num != MesgNumRecord {
+			continue
+		}
+		msg.RemoveField(3)
+		msg.SetField(Field{Num: 0, Type: BaseTypeSint32, Value: DegreesToSemicircles(30)})
+		msg.SetField(Field{Num: 7, Type: BaseTypeUint16, Value: uint16(250)})
+	}
+	for i := range decoded.Messages {
+		msg := &decoded.Messages[i]
+		if msg.Num == MesgNumDeviceInfo {
+			msg.SetField(Field{Num: 27, Type: BaseTypeString, Value: "Forerunner 945 LTE"})
+		}
+	}
+
+	data, err := EncodeBytes(decoded)
+	assert.Nil(t, err)
+	reDecoded, err := DecodeBytes(data)
+	assert.Nil(t, err)
+
+	records := reDecoded.Records()
+	assert.Len(t, records, 5)
+	for _, record := range records {
+		assert.InDelta(t, 30, record.Position.Lat, 1e-6)
+		assert.Equal(t, uint8(0), record.HeartRate)
+		assert.Equal(t, uint16(250), record.Power)
+	}
+	assert.Equal(t, "Forerunner 945 LTE", reDecoded.DeviceInfos()[0].ProductName)
+}
+
+func TestEncode_NewFile(t *testing.T) {
+	created := time.Date(2021, 5, 1, 8, 0, 0, 0, time.UTC)
+	file := &File{
+		Messages: []Message{
+			{
+				Num: MesgNumFileID,
+				Fields: []Field{
+					{Num: 0, Type: BaseTypeEnum, Value: uint8(9)},
+					{Num: 1, Type: BaseTypeUint16, Value: uint16(1)},
+					{Num: 4, Type: BaseTypeUint32, Value: TimeToFIT(created)},
+				},
+			},
+		},
+	}
+
+	data, err := EncodeBytes(file)
+	assert.Nil(t, err)
+	decoded, err := DecodeBytes(data)
+	assert.Nil(t, err)
+	assert.Equal(t, uint16(ProfileVersion), decoded.Header.ProfileVersion)
+
+	fileID, ok := decoded.FileID()
+	assert.True(t, ok)
+	assert.Equal(t, uint8(9), fileID.Type)
+	assert.Equal(t, created, fileID.TimeCreated)
+}
+
+func TestEncode_MismatchedValue(t *testing.T) {
+	file := &File{
+		Messages: []Message{
+			{
+				Num: MesgNumRecord,
+				Fields: []Field{
+					{Num: 0, Type: BaseTypeSint32, Value: int16(1)},
+				},
+			},
+		},
+	}
+
+	_, err := EncodeBytes(file)
+	assert.NotNil(t, err)
+}
+
+func TestEncode_CompressTimestampsAfterInvalid(t *testing.T) {
+	record := func(timestamp uint32, heartRate uint8) Message {
+		return Message{
+			Num: MesgNumRecord,
+			Fields: []Field{
+				{Num: FieldNumTimestamp, Type: BaseTypeUint32, Value: timestamp},
+				{Num: 3, Type: BaseTypeUint8, Value: heartRate},
+			},
+		}
+	}
+	file := &File{
+		Messages: []Message{
+			record(1000, 120),
+			record(0xFFFFFFFF, 121),
+			record(1005, 122),
+			record(1010, 123),
+		},
+	}
+
+	data, err := EncodeBytes(file, CompressTimestamps())
+	assert.Nil(t, err)
+	decoded, err := DecodeBytes(data)
+	assert.Nil(t, err)
+	assert.Len(t, decoded.Messages, 4)
+	for i, msg := range decoded.Messages {
+		timestamp, ok := messageTimestamp(&msg)
+		assert.True(t, ok)
+		assert.Equal(t, file.Messages[i].Fields[0].Value, timestamp)
+	}
+}
+
+func TestEncode_ZeroSizeFields(t *testing.T) {
+	file := &File{
+		Messages: []Message{
+			{
+				Num: MesgNumRecord,
+				Fields: []Field{
+					{Num: 0, Type: BaseTypeByte, Value: []byte(nil)},
+					{Num: 1, Type: BaseTypeUint16, Value: []uint16{}},
+					{Num: 3, Type: BaseTypeUint8, Value: uint8(120)},
+				},
+			},
+		},
+	}
+
+	data, err := EncodeBytes(file)
+	assert.Nil(t, err)
+	decoded, err := DecodeBytes(data)
+	assert.Nil(t, err)
+	assert.Len(t, decoded.Messages, 1)
+	assert.Len(t, decoded.Messages[0].Fields, 3)
+	assert.Equal(t, uint8(120), decoded.Messages[0].Fields[2].Value)
+
+	again, err := EncodeBytes(decoded)
+	assert.Nil(t, err)
+	assert.Equal(t, data, again)
+}
+
+func TestEncode_DeviceFixtureRoundTrip(t *testing.T) {
+	decoded := decodeDeviceFixture(t)
+
+	for _, options := range [][]EncoderOption{nil, {CompressTimestamps()}} {
+		encoded, err := EncodeBytes(decoded, options...)
+		assert.Nil(t, err)
+		reDecoded, err := DecodeBytes(encoded)
+		assert.Nil(t, err)
+		assert.Equal(t, withoutLocalNums(decoded.Messages), withoutLocalNums(reDecoded.Messages))
+		assert.Equal(t, CRC(0, encoded[:len(encoded)-2]), reDecoded.CRC)
+		assert.Equal(t, CRC(0, encoded[:12]), reDecoded.Header.CRC)
+
+		reEncoded, err := EncodeBytes(reDecoded, options...)
+		assert.Nil(t, err)
+		assert.Equal(t, encoded, reEncoded)
+	}
+}
@@ -0,0 +1,279 @@
+// Package fit reads and writes files in the Garmin FIT (Flexible and Interoperable Data Transfer) format.
+package fit
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+const (
+	headerSize       = 14
+	legacyHeaderSize = 12
+
+	ProtocolVersion = 0x20
+	ProfileVersion  = 2132
+)
+
+var (
+	ErrNotFIT       = errors.New("fit: not a FIT file")
+	ErrInvalidCRC   = errors.New("fit: invalid CRC")
+	ErrTruncated    = errors.New("fit: truncated file")
+	ErrUndefinedMsg = errors.New("fit: data message without definition")
+)
+
+// epoch is the origin of FIT timestamps, 1989-12-31T00:00:00Z.
+var epoch = time.Date(1989, 12, 31, 0, 0, 0, 0, time.UTC)
+
+type Header struct {
+	Size            byte
+	ProtocolVersion byte
+	ProfileVersion  uint16
+	DataSize        uint32
+	CRC             uint16
+}
+
+type File struct {
+	Header   Header
+	Messages []Message
+	CRC      uint16
+}
+
+type MesgNum uint16
+
+const (
+	MesgNumFileID           MesgNum = 0
+	MesgNumSession          MesgNum = 18
+	MesgNumLap              MesgNum = 19
+	MesgNumRecord           MesgNum = 20
+	MesgNumEvent            MesgNum = 21
+	MesgNumDeviceInfo       MesgNum = 23
+	MesgNumWeightScale      MesgNum = 30
+	MesgNumActivity         MesgNum = 34
+	MesgNumFieldDescription MesgNum = 206
+	MesgNumDeveloperDataID  MesgNum = 207
+)
+
+// File types of the file_id message.
+const (
+	FileTypeActivity uint8 = 4
+	FileTypeWeight   uint8 = 9
+)
+
+// FieldNumTimestamp is the field number of the timestamp shared by all messages.
+const FieldNumTimestamp = 253
+
+type Message struct {
+	Num MesgNum
+	// LocalNum is the local message type the message was written with
+	LocalNum        byte
+	Fields          []Field
+	DeveloperFields []DeveloperField
+}
+
+type Field struct {
+	Num  byte
+	Type BaseType
+	// Value holds a Go value matching Type: e.g. uint16 or []uint16 for BaseTypeUint16,
+	// string for BaseTypeString and []byte for BaseTypeByte, even a single byte.
+	Value interface{}
+}
+
+type DeveloperField struct {
+	Num                byte
+	DeveloperDataIndex byte
+	Type               BaseType
+	Value              interface{}
+}
+
+func (m *Message) Field(num byte) *Field {
+	for i := range m.Fields {
+		if m.Fields[i].Num == num {
+			return &m.Fields[i]
+		}
+	}
+	return nil
+}
+
+// SetField replaces the field with the same number, or appends it.
+func (m *Message) SetField(field Field) {
+	if f := m.Field(field.Num); f != nil {
+		*f = field
+		return
+	}
+	m.Fields = append(m.Fields, field)
+}
+
+func (m *Message) RemoveField(num byte) bool {
+	for i := range m.Fields {
+		if m.Fields[i].Num == num {
+			m.Fields = append(m.Fields[:i], m.Fields[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Message) DeveloperField(developerDataIndex byte, num byte) *DeveloperField {
+	for i := range m.DeveloperFields {
+		if m.DeveloperFields[i].DeveloperDataIndex == developerDataIndex && m.DeveloperFields[i].Num == num {
+			return &m.DeveloperFields[i]
+		}
+	}
+	return nil
+}
+
+func (m *Message) Timestamp() (time.Time, bool) {
+	return m.timeField(FieldNumTimestamp)
+}
+
+func (m *Message) uintField(num byte) (uint64, bool) {
+	if f := m.Field(num); f != nil {
+		return f.Uint()
+	}
+	return 0, false
+}
+
+func (m *Message) intField(num byte) (int64, bool) {
+	if f := m.Field(num); f != nil {
+		return f.Int()
+	}
+	return 0, false
+}
+
+func (m *Message) scaledField(num byte, scale float64, offset float64) (float64, bool) {
+	if f := m.Field(num); f != nil {
+		if v, ok := f.Float(); ok {
+			return v/scale - offset, true
+		}
+	}
+	return 0, false
+}
+
+func (m *Message) stringField(num byte) string {
+	if f := m.Field(num); f != nil {
+		if s, ok := f.Value.(string); ok {
+			return s
+		}
+	}
+	return ""
+}
+
+func (m *Message) timeField(num byte) (time.Time, bool) {
+	v, ok := m.uintField(num)
+	if !ok {
+		return time.Time{}, false
+	}
+	return TimeFromFIT(uint32(v)), true
+}
+
+func (m *Message) positionField(latNum byte, longNum byte) (Position, bool) {
+	lat, latOk := m.intField(latNum)
+	long, longOk := m.intField(longNum)
+	if !latOk || !longOk {
+		return Position{}, false
+	}
+	return Position{
+		Lat:  SemicirclesToDegrees(int32(lat)),
+		Long: SemicirclesToDegrees(int32(long)),
+	}, true
+}
+
+// Uint returns the value of an unsigned or signed integer field, or false if it is invalid or not an integer.
+func (f *Field) Uint() (uint64, bool) {
+	if !f.IsValid() {
+		return 0, false
+	}
+	switch v := f.Value.(type) {
+	case uint8:
+		return uint64(v), true
+	case uint16:
+		return uint64(v), true
+	case uint32:
+		return uint64(v), true
+	case uint64:
+		return v, true
+	case int8:
+		return uint64(v), v >= 0
+	case int16:
+		return uint64(v), v >= 0
+	case int32:
+		return uint64(v), v >= 0
+	case int64:
+		return uint64(v), v >= 0
+	}
+	return 0, false
+}
+
+func (f *Field) Int() (int64, bool) {
+	if !f.IsValid() {
+		return 0, false
+	}
+	switch v := f.Value.(type) {
+	case int8:
+		return int64(v), true
+	case int16:
+		return int64(v), true
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	case uint8:
+		return int64(v), true
+	case uint16:
+		return int64(v), true
+	case uint32:
+		return int64(v), true
+	case uint64:
+		return int64(v), v <= math.MaxInt64
+	}
+	return 0, false
+}
+
+func (f *Field) Float() (float64, bool) {
+	if !f.IsValid() {
+		return 0, false
+	}
+	switch v := f.Value.(type) {
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	if i, ok := f.Int(); ok {
+		return float64(i), true
+	}
+	if u, ok := f.Uint(); ok {
+		return float64(u), true
+	}
+	return 0, false
+}
+
+// IsValid reports whether the field holds a value other than the invalid value of its base type.
+// An array is valid as soon as one of its elements is.
+func (f *Field) IsValid() bool {
+	return isValidValue(f.Type, f.Value)
+}
+
+// TimeFromFIT converts a FIT date_time to a time.
+func TimeFromFIT(timestamp uint32) time.Time {
+	return epoch.Add(time.Duration(timestamp) * time.Second)
+}
+
+// TimeToFIT converts a time to a FIT date_time.
+func TimeToFIT(t time.Time) uint32 {
+	return uint32(t.Sub(epoch) / time.Second)
+}
+
+type Position struct {
+	Lat  float64
+	Long float64
+}
+
+func SemicirclesToDegrees(semicircles int32) float64 {
+	return float64(semicircles) * 180 / (1 << 31)
+}
+
+func DegreesToSemicircles(degrees float64) int32 {
+	return int32(math.Round(degrees * (1 << 31) / 180))
+}
@@ -0,0 +1,362 @@
+package fit
+
+import "time"
+
+// The typed messages below are read-only views over the fields most commonly
+// needed. Fields that are missing or invalid are left at their zero value.
+
+type FileID struct {
+	Type         uint8
+	Manufacturer uint16
+	Product      uint16
+	SerialNumber uint32
+	TimeCreated  time.Time
+	ProductName  string
+}
+
+type Record struct {
+	Timestamp   time.Time
+	Position    Position
+	HasPosition bool
+	// Altitude in meters
+	Altitude float64
+	// Distance in meters
+	Distance float64
+	// Speed in meters per second
+	Speed       float64
+	HeartRate   uint8
+	Cadence     uint8
+	Power       uint16
+	Temperature int8
+}
+
+type Lap struct {
+	Timestamp        time.Time
+	StartTime        time.Time
+	StartPosition    Position
+	HasStartPosition bool
+	EndPosition      Position
+	HasEndPosition   bool
+	TotalElapsedTime time.Duration
+	TotalTimerTime   time.Duration
+	TotalDistance    float64
+	TotalCalories    uint16
+	AvgHeartRate     uint8
+	MaxHeartRate     uint8
+	Sport            uint8
+}
+
+type Session struct {
+	Timestamp        time.Time
+	StartTime        time.Time
+	StartPosition    Position
+	HasStartPosition bool
+	Sport            uint8
+	SubSport         uint8
+	TotalElapsedTime time.Duration
+	TotalTimerTime   time.Duration
+	TotalDistance    float64
+	TotalCalories    uint16
+	AvgHeartRate     uint8
+	MaxHeartRate     uint8
+	NumLaps          uint16
+}
+
+type DeviceInfo struct {
+	Timestamp       time.Time
+	DeviceIndex     uint8
+	DeviceType      uint8
+	Manufacturer    uint16
+	SerialNumber    uint32
+	Product         uint16
+	SoftwareVersion float64
+	ProductName     string
+}
+
+type Event struct {
+	Timestamp  time.Time
+	Event      uint8
+	EventType  uint8
+	Data       uint32
+	EventGroup uint8
+}
+
+type WeightScale struct {
+	Timestamp time.Time
+	// Weight, BoneMass and MuscleMass in kilograms
+	Weight            float64
+	PercentFat        float64
+	PercentHydration  float64
+	BoneMass          float64
+	MuscleMass        float64
+	PhysiqueRating    uint8
+	MetabolicAge      uint8
+	VisceralFatRating uint8
+	BMI               float64
+}
+
+type FieldDescription struct {
+	DeveloperDataIndex    byte
+	FieldDefinitionNumber byte
+	BaseType              BaseType
+	FieldName             string
+	Units                 string
+}
+
+func (f *File) FileID() (FileID, bool) {
+	for i := range f.Messages {
+		m := &f.Messages[i]
+		if m.Num != MesgNumFileID {
+			continue
+		}
+		fileID := FileID{
+			ProductName: m.stringField(8),
+		}
+		if v, ok := m.uintField(0); ok {
+			fileID.Type = uint8(v)
+		}
+		if v, ok := m.uintField(1); ok {
+			fileID.Manufacturer = uint16(v)
+		}
+		if v, ok := m.uintField(2); ok {
+			fileID.Product = uint16(v)
+		}
+		if v, ok := m.uintField(3); ok {
+			fileID.SerialNumber = uint32(v)
+		}
+		fileID.TimeCreated, _ = m.timeField(4)
+		return fileID, true
+	}
+	return FileID{}, false
+}
+
+func (f *File) Records() []Record {
+	records := make([]Record, 0)
+	for i := range f.Messages {
+		m := &f.Messages[i]
+		if m.Num != MesgNumRecord {
+			continue
+		}
+		record := Record{}
+		record.Timestamp, _ = m.Timestamp()
+		record.Position, record.HasPosition = m.positionField(0, 1)
+		if v, ok := m.scaledField(78, 5, 500); ok {
+			record.Altitude = v
+		} else if v, ok := m.scaledField(2, 5, 500); ok {
+			record.Altitude = v
+		}
+		record.Distance, _ = m.scaledField(5, 100, 0)
+		if v, ok := m.scaledField(73, 1000, 0); ok {
+			record.Speed = v
+		} else if v, ok := m.scaledField(6, 1000, 0); ok {
+			record.Speed = v
+		}
+		if v, ok := m.uintField(3); ok {
+			record.HeartRate = uint8(v)
+		}
+		if v, ok := m.uintField(4); ok {
+			record.Cadence = uint8(v)
+		}
+		if v, ok := m.uintField(7); ok {
+			record.Power = uint16(v)
+		}
+		if v, ok := m.intField(13); ok {
+			record.Temperature = int8(v)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func (f *File) Laps() []Lap {
+	laps := make([]Lap, 0)
+	for i := range f.Messages {
+		m := &f.Messages[i]
+		if m.Num != MesgNumLap {
+			continue
+		}
+		lap := Lap{}
+		lap.Timestamp, _ = m.Timestamp()
+		lap.StartTime, _ = m.timeField(2)
+		lap.StartPosition, lap.HasStartPosition = m.positionField(3, 4)
+		lap.EndPosition, lap.HasEndPosition = m.positionField(5, 6)
+		lap.TotalElapsedTime = m.durationField(7)
+		lap.TotalTimerTime = m.durationField(8)
+		lap.TotalDistance, _ = m.scaledField(9, 100, 0)
+		if v, ok := m.uintField(11); ok {
+			lap.TotalCalories = uint16(v)
+		}
+		if v, ok := m.uintField(15); ok {
+			lap.AvgHeartRate = uint8(v)
+		}
+		if v, ok := m.uintField(16); ok {
+			lap.MaxHeartRate = uint8(v)
+		}
+		if v, ok := m.uintField(25); ok {
+			lap.Sport = uint8(v)
+		}
+		laps = append(laps, lap)
+	}
+	return laps
+}
+
+func (f *File) Sessions() []Session {
+	sessions := make([]Session, 0)
+	for i := range f.Messages {
+		m := &f.Messages[i]
+		if m.Num != MesgNumSession {
+			continue
+		}
+		session := Session{}
+		session.Timestamp, _ = m.Timestamp()
+		session.StartTime, _ = m.timeField(2)
+		session.StartPosition, session.HasStartPosition = m.positionField(3, 4)
+		if v, ok := m.uintField(5); ok {
+			session.Sport = uint8(v)
+		}
+		if v, ok := m.uintField(6); ok {
+			session.SubSport = uint8(v)
+		}
+		session.TotalElapsedTime = m.durationField(7)
+		session.TotalTimerTime = m.durationField(8)
+		session.TotalDistance, _ = m.scaledField(9, 100, 0)
+		if v, ok := m.uintField(11); ok {
+			session.TotalCalories = uint16(v)
+		}
+		if v, ok := m.uintField(16); ok {
+			session.AvgHeartRate = uint8(v)
+		}
+		if v, ok := m.uintField(17); ok {
+			session.MaxHeartRate = uint8(v)
+		}
+		if v, ok := m.uintField(26); ok {
+			session.NumLaps = uint16(v)
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions
+}
+
+func (f *File) DeviceInfos() []DeviceInfo {
+	deviceInfos := make([]DeviceInfo, 0)
+	for i := range f.Messages {
+		m := &f.Messages[i]
+		if m.Num != MesgNumDeviceInfo {
+			continue
+		}
+		deviceInfo := DeviceInfo{
+			ProductName: m.stringField(27),
+		}
+		deviceInfo.Timestamp, _ = m.Timestamp()
+		if v, ok := m.uintField(0); ok {
+			deviceInfo.DeviceIndex = uint8(v)
+		}
+		if v, ok := m.uintField(1); ok {
+			deviceInfo.DeviceType = uint8(v)
+		}
+		if v, ok := m.uintField(2); ok {
+			deviceInfo.Manufacturer = uint16(v)
+		}
+		if v, ok := m.uintField(3); ok {
+			deviceInfo.SerialNumber = uint32(v)
+		}
+		if v, ok := m.uintField(4); ok {
+			deviceInfo.Product = uint16(v)
+		}
+		deviceInfo.SoftwareVersion, _ = m.scaledField(5, 100, 0)
+		deviceInfos = append(deviceInfos, deviceInfo)
+	}
+	return deviceInfos
+}
+
+func (f *File) Events() []Event {
+	events := make([]Event, 0)
+	for i := range f.Messages {
+		m := &f.Messages[i]
+		if m.Num != MesgNumEvent {
+			continue
+		}
+		event := Event{}
+		event.Timestamp, _ = m.Timestamp()
+		if v, ok := m.uintField(0); ok {
+			event.Event = uint8(v)
+		}
+		if v, ok := m.uintField(1); ok {
+			event.EventType = uint8(v)
+		}
+		if v, ok := m.uintField(3); ok {
+			event.Data = uint32(v)
+		}
+		if v, ok := m.uintField(4); ok {
+			event.EventGroup = uint8(v)
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+func (f *File) WeightScales() []WeightScale {
+	weightScales := make([]WeightScale, 0)
+	for i := range f.Messages {
+		m := &f.Messages[i]
+		if m.Num != MesgNumWeightScale {
+			continue
+		}
+		weightScale := WeightScale{}
+		weightScale.Timestamp, _ = m.Timestamp()
+		weightScale.Weight, _ = m.scaledField(0, 100, 0)
+		weightScale.PercentFat, _ = m.scaledField(1, 100, 0)
+		weightScale.PercentHydration, _ = m.scaledField(2, 100, 0)
+		weightScale.BoneMass, _ = m.scaledField(4, 100, 0)
+		weightScale.MuscleMass, _ = m.scaledField(5, 100, 0)
+		if v, ok := m.uintField(8); ok {
+			weightScale.PhysiqueRating = uint8(v)
+		}
+		if v, ok := m.uintField(10); ok {
+			weightScale.MetabolicAge = uint8(v)
+		}
+		if v, ok := m.uintField(11); ok {
+			weightScale.VisceralFatRating = uint8(v)
+		}
+		weightScale.BMI, _ = m.scaledField(13, 10, 0)
+		weightScales = append(weightScales, weightScale)
+	}
+	return weightScales
+}
+
+func (f *File) FieldDescriptions() []FieldDescription {
+	descriptions := make([]FieldDescription, 0)
+	for i := range f.Messages {
+		if f.Messages[i].Num == MesgNumFieldDescription {
+			descriptions = append(descriptions, newFieldDescription(&f.Messages[i]))
+		}
+	}
+	return descriptions
+}
+
+func newFieldDescription(m *Message) FieldDescription {
+	description := FieldDescription{
+		BaseType:  BaseTypeByte,
+		FieldName: m.stringField(3),
+		Units:     m.stringField(8),
+	}
+	if v, ok := m.uintField(0); ok {
+		description.DeveloperDataIndex = byte(v)
+	}
+	if v, ok := m.uintField(1); ok {
+		description.FieldDefinitionNumber = byte(v)
+	}
+	if v, ok := m.uintField(2); ok {
+		description.BaseType = normalizeBaseType(byte(v))
+	}
+	return description
+}
+
+// durationField reads a uint32 field in milliseconds, as used by elapsed and timer times.
+func (m *Message) durationField(num byte) time.Duration {
+	v, ok := m.uintField(num)
+	if !ok {
+		return 0
+	}
+	return time.Duration(v) * time.Millisecond
+}
@@ -1,14 +1,20 @@
 package garmin
 
 type Activity struct {
-	ActivityId         int64        `json:"activityId"`
-	ActivityName       string       `json:"activityName"`
-	Description        string       `json:"description"`
-	UserProfileId      int          `json:"userProfileId"`
-	IsMultiSportParent bool         `json:"isMultiSportParent"`
-	ActivityType       ActivityType `json:"activityTypeDTO"`
-	Summary            Summary      `json:"summaryDTO"`
-	MetadataDTO        MetaData     `json:"metadataDTO"`
+	ActivityId         int64         `json:"activityId"`
+	ActivityName       string        `json:"activityName"`
+	Description        string        `json:"description"`
+	UserProfileId      int           `json:"userProfileId"`
+	IsMultiSportParent bool          `json:"isMultiSportParent"`
+	ActivityType       ActivityType  `json:"activityTypeDTO"`
+	Summary            Summary       `json:"summaryDTO"`
+	MetadataDTO        MetaData      `json:"metadataDTO"`
+	AccessControlRule  AccessControl `json:"accessControlRuleDTO"`
+}
+
+type AccessControl struct {
+	TypeId  int    `json:"typeId"`
+	TypeKey string `json:"typeKey"`
 }
 
 type ActivityType struct {
@@ -60,3 +66,33 @@ type Sensor struct {
 	SoftwareVersion float32 `json:"softwareVersion"`
 	LocalDeviceType string  `json:"localDeviceType"`
 }
+
+// ListItem returns the activity as the activity list would, for activities
+// fetched one by one.
+func (a Activity) ListItem() ActivityListItem {
+	return ActivityListItem{
+		ActivityId:      a.ActivityId,
+		ActivityName:    a.ActivityName,
+		Description:     a.Description,
+		StartTimeLocal:  a.Summary.StartTimeLocal,
+		StartTimeGMT:    a.Summary.StartTimeGMT,
+		ActivityType:    a.ActivityType,
+		Distance:        a.Summary.Distance,
+		Duration:        a.Summary.Duration,
+		ElapsedDuration: a.Summary.ElapsedDuration,
+		MovingDuration:  a.Summary.MovingDuration,
+		ElevationGain:   a.Summary.ElevationGain,
+		ElevationLoss:   a.Summary.ElevationLoss,
+		AverageSpeed:    a.Summary.AverageSpeed,
+		MaxSpeed:        a.Summary.MaxSpeed,
+		StartLatitude:   a.Summary.StartLatitude,
+		StartLongitude:  a.Summary.StartLongitude,
+		Calories:        a.Summary.Calories,
+		AverageHR:       a.Summary.AverageHR,
+		MaxHR:           a.Summary.MaxHR,
+		OwnerId:         int64(a.UserProfileId),
+		Manufacturer:    a.MetadataDTO.Manufacturer,
+		Privacy:         a.AccessControlRule,
+		Parent:          a.IsMultiSportParent,
+	}
+}
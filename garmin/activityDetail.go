@@ -0,0 +1,129 @@
+package garmin
+
+type ActivitySplits struct {
+	ActivityId int64   `json:"activityId"`
+	Laps       []Split `json:"lapDTOs"`
+}
+
+type Split struct {
+	LapIndex        int     `json:"lapIndex"`
+	StartTimeGMT    string  `json:"startTimeGMT"`
+	StartLatitude   float64 `json:"startLatitude"`
+	StartLongitude  float64 `json:"startLongitude"`
+	EndLatitude     float64 `json:"endLatitude"`
+	EndLongitude    float64 `json:"endLongitude"`
+	Distance        float64 `json:"distance"`
+	Duration        float64 `json:"duration"`
+	MovingDuration  float64 `json:"movingDuration"`
+	ElapsedDuration float64 `json:"elapsedDuration"`
+	ElevationGain   float64 `json:"elevationGain"`
+	ElevationLoss   float64 `json:"elevationLoss"`
+	AverageSpeed    float64 `json:"averageSpeed"`
+	MaxSpeed        float64 `json:"maxSpeed"`
+	Calories        float64 `json:"calories"`
+	AverageHR       float64 `json:"averageHR"`
+	MaxHR           float64 `json:"maxHR"`
+	AveragePower    float64 `json:"averagePower"`
+	MaxPower        float64 `json:"maxPower"`
+	AverageCadence  float64 `json:"averageRunCadence"`
+}
+
+// ActivityDetails holds the time series of an activity. Each entry of Metrics
+// holds one sample per descriptor, nil where the metric was not recorded.
+type ActivityDetails struct {
+	ActivityId        int64              `json:"activityId"`
+	MeasurementCount  int                `json:"measurementCount"`
+	MetricsCount      int                `json:"metricsCount"`
+	MetricDescriptors []MetricDescriptor `json:"metricDescriptors"`
+	Metrics           []DetailMetrics    `json:"activityDetailMetrics"`
+	DetailsAvailable  bool               `json:"detailsAvailable"`
+}
+
+type MetricDescriptor struct {
+	MetricsIndex int        `json:"metricsIndex"`
+	Key          string     `json:"key"`
+	Unit         MetricUnit `json:"unit"`
+}
+
+type MetricUnit struct {
+	Id     int     `json:"id"`
+	Key    string  `json:"key"`
+	Factor float64 `json:"factor"`
+}
+
+type DetailMetrics struct {
+	Metrics []*float64 `json:"metrics"`
+}
+
+// Metric returns the samples of the metric with key, e.g. "directHeartRate", or nil if it was not recorded.
+func (d ActivityDetails) Metric(key string) []*float64 {
+	for _, descriptor := range d.MetricDescriptors {
+		if descriptor.Key != key {
+			continue
+		}
+		samples := make([]*float64, len(d.Metrics))
+		for i, m := range d.Metrics {
+			if descriptor.MetricsIndex < len(m.Metrics) {
+				samples[i] = m.Metrics[descriptor.MetricsIndex]
+			}
+		}
+		return samples
+	}
+	return nil
+}
+
+type TimeInZone struct {
+	ZoneNumber      int     `json:"zoneNumber"`
+	SecsInZone      float64 `json:"secsInZone"`
+	ZoneLowBoundary float64 `json:"zoneLowBoundary"`
+}
+
+type Weather struct {
+	IssueDate                 string         `json:"issueDate"`
+	Temp                      float64        `json:"temp"`
+	ApparentTemp              float64        `json:"apparentTemp"`
+	DewPoint                  float64        `json:"dewPoint"`
+	RelativeHumidity          float64        `json:"relativeHumidity"`
+	WindDirection             float64        `json:"windDirection"`
+	WindDirectionCompassPoint string         `json:"windDirectionCompassPoint"`
+	WindSpeed                 float64        `json:"windSpeed"`
+	WindGust                  float64        `json:"windGust"`
+	Latitude                  float64        `json:"latitude"`
+	Longitude                 float64        `json:"longitude"`
+	WeatherStation            WeatherStation `json:"weatherStationDTO"`
+	WeatherType               WeatherType    `json:"weatherTypeDTO"`
+}
+
+type WeatherStation struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type WeatherType struct {
+	WeatherTypePk int    `json:"weatherTypePk"`
+	Desc          string `json:"desc"`
+	Image         string `json:"image"`
+}
+
+type ExerciseSets struct {
+	ActivityId   int64         `json:"activityId"`
+	ExerciseSets []ExerciseSet `json:"exerciseSets"`
+}
+
+type ExerciseSet struct {
+	Exercises       []Exercise `json:"exercises"`
+	Duration        float64    `json:"duration"`
+	RepetitionCount int        `json:"repetitionCount"`
+	// Weight in grams
+	Weight       float64 `json:"weight"`
+	SetType      string  `json:"setType"`
+	StartTime    string  `json:"startTime"`
+	WktStepIndex int     `json:"wktStepIndex"`
+	MessageIndex int     `json:"messageIndex"`
+}
+
+type Exercise struct {
+	Category    string  `json:"category"`
+	Name        string  `json:"name"`
+	Probability float64 `json:"probability"`
+}
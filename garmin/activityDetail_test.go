@@ -0,0 +1,64 @@
+package garmin
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+const activityDetailsJson = `{
+	"activityId": 7654321,
+	"measurementCount": 3,
+	"metricsCount": 3,
+	"metricDescriptors": [
+		{"metricsIndex": 0, "key": "directTimestamp", "unit": {"id": 120, "key": "gmt", "factor": 0.0}},
+		{"metricsIndex": 2, "key": "directHeartRate", "unit": {"id": 100, "key": "bpm", "factor": 1.0}},
+		{"metricsIndex": 1, "key": "directSpeed", "unit": {"id": 20, "key": "mps", "factor": 0.1}}
+	],
+	"activityDetailMetrics": [
+		{"metrics": [1631065600000.0, 3.0, 120.0]},
+		{"metrics": [1631065601000.0, 3.1, null]},
+		{"metrics": [1631065602000.0, 3.2]}
+	],
+	"detailsAvailable": true
+}`
+
+func TestActivityDetails_Metric(t *testing.T) {
+	details := ActivityDetails{}
+	assert.Nil(t, json.Unmarshal([]byte(activityDetailsJson), &details))
+	assert.True(t, details.DetailsAvailable)
+	assert.Len(t, details.Metrics, 3)
+
+	heartRate := details.Metric("directHeartRate")
+	assert.Len(t, heartRate, 3)
+	assert.Equal(t, 120.0, *heartRate[0])
+	assert.Nil(t, heartRate[1])
+	// a short sample has no value for the trailing metrics
+	assert.Nil(t, heartRate[2])
+
+	speed := details.Metric("directSpeed")
+	assert.Equal(t, 3.2, *speed[2])
+
+	assert.Nil(t, details.Metric("directPower"))
+}
+
+func TestExerciseSets_Unmarshal(t *testing.T) {
+	sets := ExerciseSets{}
+	assert.Nil(t, json.Unmarshal([]byte(`{
+		"activityId": 7654321,
+		"exerciseSets": [{
+			"exercises": [{"category": "BENCH_PRESS", "name": "BARBELL_BENCH_PRESS", "probability": 97.5}],
+			"duration": 35.2,
+			"repetitionCount": 10,
+			"weight": 60000.0,
+			"setType": "ACTIVE",
+			"startTime": "2021-09-08T01:46:40.0",
+			"wktStepIndex": null,
+			"messageIndex": 0
+		}]
+	}`), &sets))
+	assert.Len(t, sets.ExerciseSets, 1)
+	assert.Equal(t, "BENCH_PRESS", sets.ExerciseSets[0].Exercises[0].Category)
+	assert.Equal(t, 10, sets.ExerciseSets[0].RepetitionCount)
+	assert.Equal(t, 60000.0, sets.ExerciseSets[0].Weight)
+}
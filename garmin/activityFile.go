@@ -0,0 +1,151 @@
+package garmin
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"path"
+	"strings"
+)
+
+type Format string
+
+const (
+	// FormatOriginal is the file recorded by the device, usually FIT.
+	FormatOriginal Format = "original"
+	FormatFIT      Format = "fit"
+	FormatTCX      Format = "tcx"
+	FormatGPX      Format = "gpx"
+	FormatKML      Format = "kml"
+	FormatCSV      Format = "csv"
+)
+
+var formatContentTypes = map[Format]string{
+	FormatFIT: "application/vnd.ant.fit",
+	FormatTCX: "application/vnd.garmin.tcx+xml",
+	FormatGPX: "application/gpx+xml",
+	FormatKML: "application/vnd.google-earth.kml+xml",
+	FormatCSV: "text/csv",
+}
+
+type ActivityFile struct {
+	FileName    string `json:"fileName"`
+	ContentType string `json:"contentType"`
+	Format      Format `json:"format"`
+	Data        []byte `json:"-"`
+}
+
+func NewActivityFile(fileName string, data []byte) ActivityFile {
+	format := FormatOfFileName(fileName)
+	contentType, ok := formatContentTypes[format]
+	if !ok {
+		contentType = "application/octet-stream"
+	}
+	return ActivityFile{
+		FileName:    fileName,
+		ContentType: contentType,
+		Format:      format,
+		Data:        data,
+	}
+}
+
+func (f ActivityFile) Reader() io.ReadCloser {
+	return ioutil.NopCloser(bytes.NewReader(f.Data))
+}
+
+// FormatOfFileName returns the format matching the extension of fileName, or "" if it is unknown.
+func FormatOfFileName(fileName string) Format {
+	format := Format(strings.TrimPrefix(strings.ToLower(path.Ext(fileName)), "."))
+	if _, ok := formatContentTypes[format]; !ok {
+		return ""
+	}
+	return format
+}
+
+var uploadFormats = map[Format]bool{
+	FormatFIT: true,
+	FormatTCX: true,
+	FormatGPX: true,
+}
+
+// DetectFormat sniffs the content of an activity file and falls back to the
+// extension of fileName when the content is not recognized.
+func DetectFormat(fileName string, data []byte) Format {
+	if format := sniffFormat(data); format != "" {
+		return format
+	}
+	return FormatOfFileName(fileName)
+}
+
+func sniffFormat(data []byte) Format {
+	// FIT files start with a 12 or 14 byte header holding ".FIT" at offset 8
+	if len(data) >= 12 && (data[0] == 12 || data[0] == 14) && string(data[8:12]) == ".FIT" {
+		return FormatFIT
+	}
+
+	head := data
+	if len(head) > 1024 {
+		head = head[:1024]
+	}
+	head = bytes.TrimPrefix(head, []byte("\xef\xbb\xbf"))
+	head = bytes.TrimSpace(head)
+	if !bytes.HasPrefix(head, []byte("<")) {
+		return ""
+	}
+	switch {
+	case bytes.Contains(head, []byte("<TrainingCenterDatabase")):
+		return FormatTCX
+	case bytes.Contains(head, []byte("<gpx")):
+		return FormatGPX
+	case bytes.Contains(head, []byte("<kml")):
+		return FormatKML
+	}
+	return ""
+}
+
+// fileNameWithFormat makes sure the extension of fileName matches format.
+func fileNameWithFormat(fileName string, format Format) string {
+	if FormatOfFileName(fileName) == format {
+		return fileName
+	}
+	return strings.TrimSuffix(fileName, path.Ext(fileName)) + "." + string(format)
+}
+
+// unzipActivityFiles returns the FIT, TCX and GPX files of an original download.
+// Multisport activities and some devices put more than one file in the archive,
+// other files such as notes are left out.
+func unzipActivityFiles(content []byte) ([]ActivityFile, error) {
+	zipReader, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]ActivityFile, 0, len(zipReader.File))
+	for _, zipFile := range zipReader.File {
+		if zipFile.FileInfo().IsDir() {
+			continue
+		}
+		file, err := zipFile.Open()
+		if err != nil {
+			return nil, err
+		}
+		data, err := ioutil.ReadAll(file)
+		_ = file.Close()
+		if err != nil {
+			return nil, err
+		}
+		name := path.Base(zipFile.Name)
+		format := DetectFormat(name, data)
+		if !uploadFormats[format] {
+			continue
+		}
+		files = append(files, NewActivityFile(fileNameWithFormat(name, format), data))
+	}
+
+	if len(files) == 0 {
+		return nil, errors.New("not file in zip file")
+	}
+	return files, nil
+}
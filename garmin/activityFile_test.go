@@ -0,0 +1,59 @@
+package garmin
+
+import (
+	"archive/zip"
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestUnzipActivityFiles(t *testing.T) {
+	buf := &bytes.Buffer{}
+	writer := zip.NewWriter(buf)
+	gpx := "<?xml version=\"1.0\"?>\n<gpx version=\"1.1\"></gpx>"
+	for _, name := range []string{"123_ACTIVITY.fit", "123_ACTIVITY_1.fit", "notes.txt", "123_ACTIVITY_2.xml"} {
+		w, err := writer.Create(name)
+		assert.Nil(t, err)
+		data := name
+		if name == "123_ACTIVITY_2.xml" {
+			data = gpx
+		}
+		_, err = w.Write([]byte(data))
+		assert.Nil(t, err)
+	}
+	assert.Nil(t, writer.Close())
+
+	files, err := unzipActivityFiles(buf.Bytes())
+	assert.Nil(t, err)
+	assert.Len(t, files, 3)
+	assert.Equal(t, "123_ACTIVITY_1.fit", files[1].FileName)
+	assert.Equal(t, FormatFIT, files[1].Format)
+	assert.Equal(t, []byte("123_ACTIVITY_1.fit"), files[1].Data)
+	assert.Equal(t, "123_ACTIVITY_2.gpx", files[2].FileName)
+	assert.Equal(t, FormatGPX, files[2].Format)
+}
+
+func TestFormatOfFileName(t *testing.T) {
+	assert.Equal(t, FormatFIT, FormatOfFileName("123_ACTIVITY.FIT"))
+	assert.Equal(t, FormatGPX, FormatOfFileName("activity_123.gpx"))
+	assert.Equal(t, Format(""), FormatOfFileName("activity_123"))
+}
+
+func TestDetectFormat(t *testing.T) {
+	fitHeader := []byte{14, 0x10, 0xd9, 0x07, 0, 0, 0, 0, '.', 'F', 'I', 'T', 0, 0}
+	tcx := []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<TrainingCenterDatabase xmlns="http://www.garmin.com/xmlschemas/TrainingCenterDatabase/v2"></TrainingCenterDatabase>`)
+	gpx := []byte("\xef\xbb\xbf<?xml version=\"1.0\"?>\n<gpx version=\"1.1\" creator=\"Garmin Connect\"></gpx>")
+
+	assert.Equal(t, FormatFIT, DetectFormat("123_ACTIVITY.fit", fitHeader))
+	assert.Equal(t, FormatFIT, DetectFormat("123_ACTIVITY.gpx", fitHeader))
+	assert.Equal(t, FormatTCX, DetectFormat("123_ACTIVITY.fit", tcx))
+	assert.Equal(t, FormatGPX, DetectFormat("123_ACTIVITY", gpx))
+	assert.Equal(t, FormatTCX, DetectFormat("123_ACTIVITY.tcx", []byte("garbage")))
+}
+
+func TestFileNameWithFormat(t *testing.T) {
+	assert.Equal(t, "123_ACTIVITY.fit", fileNameWithFormat("123_ACTIVITY.fit", FormatFIT))
+	assert.Equal(t, "123_ACTIVITY.tcx", fileNameWithFormat("123_ACTIVITY.fit", FormatTCX))
+	assert.Equal(t, "123_ACTIVITY.gpx", fileNameWithFormat("123_ACTIVITY", FormatGPX))
+}
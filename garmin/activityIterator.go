@@ -0,0 +1,132 @@
+package garmin
+
+import (
+	"context"
+	"time"
+)
+
+// MaxActivityPageSize is the largest page the activity list returns in one request.
+const MaxActivityPageSize = 100
+
+// ActivityFilter narrows the activities returned by Client.Activities. Zero values are ignored.
+type ActivityFilter struct {
+	// StartDate and EndDate are compared by day, both inclusive
+	StartDate    time.Time
+	EndDate      time.Time
+	ActivityType string
+	// Limit stops the iteration after Limit activities
+	Limit int
+	// PageSize defaults to, and is capped at, MaxActivityPageSize
+	PageSize int
+}
+
+func (f ActivityFilter) params() map[string]interface{} {
+	params := make(map[string]interface{})
+	if !f.StartDate.IsZero() {
+		params["startDate"] = f.StartDate.Format("2006-01-02")
+	}
+	if !f.EndDate.IsZero() {
+		params["endDate"] = f.EndDate.Format("2006-01-02")
+	}
+	if f.ActivityType != "" {
+		params["activityType"] = f.ActivityType
+	}
+	return params
+}
+
+type activityPageFetcher func(start int64, limit int64) ([]ActivityListItem, error)
+
+// ActivityIterator pages through the activity list, newest first:
+//
+//	it := client.Activities(ctx, garmin.ActivityFilter{StartDate: since})
+//	for it.Next() {
+//		activity := it.Activity()
+//	}
+//	if err := it.Err(); err != nil {
+//	}
+type ActivityIterator struct {
+	ctx    context.Context
+	filter ActivityFilter
+	fetch  activityPageFetcher
+
+	page    []ActivityListItem
+	index   int
+	start   int64
+	count   int
+	last    bool
+	current ActivityListItem
+	err     error
+}
+
+func (c *Client) Activities(ctx context.Context, filter ActivityFilter) *ActivityIterator {
+	return newActivityIterator(ctx, filter, func(start int64, limit int64) ([]ActivityListItem, error) {
+		return c.searchActivities(start, limit, filter.params())
+	})
+}
+
+func newActivityIterator(ctx context.Context, filter ActivityFilter, fetch activityPageFetcher) *ActivityIterator {
+	if filter.PageSize <= 0 || filter.PageSize > MaxActivityPageSize {
+		filter.PageSize = MaxActivityPageSize
+	}
+	return &ActivityIterator{
+		ctx:    ctx,
+		filter: filter,
+		fetch:  fetch,
+	}
+}
+
+// Next advances to the next activity, fetching the next page when needed. It
+// returns false at the end of the list, on error or once the context is done.
+func (it *ActivityIterator) Next() bool {
+	if it.err != nil || it.filter.Limit > 0 && it.count >= it.filter.Limit {
+		return false
+	}
+	if it.index >= len(it.page) {
+		if it.last {
+			return false
+		}
+		if err := it.ctx.Err(); err != nil {
+			it.err = err
+			return false
+		}
+
+		limit := it.filter.PageSize
+		if remaining := it.filter.Limit - it.count; it.filter.Limit > 0 && remaining < limit {
+			limit = remaining
+		}
+		page, err := it.fetch(it.start, int64(limit))
+		if err != nil {
+			it.err = err
+			return false
+		}
+		it.page, it.index = page, 0
+		it.start += int64(len(page))
+		// NOTE: a short page is the last one, so the end costs no extra empty request
+		it.last = len(page) < limit
+		if len(page) == 0 {
+			return false
+		}
+	}
+
+	it.current = it.page[it.index]
+	it.index++
+	it.count++
+	return true
+}
+
+func (it *ActivityIterator) Activity() ActivityListItem {
+	return it.current
+}
+
+func (it *ActivityIterator) Err() error {
+	return it.err
+}
+
+// All drains the iterator.
+func (it *ActivityIterator) All() ([]ActivityListItem, error) {
+	activityList := make([]ActivityListItem, 0)
+	for it.Next() {
+		activityList = append(activityList, it.Activity())
+	}
+	return activityList, it.Err()
+}
@@ -0,0 +1,103 @@
+package garmin
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+type fakeActivityList struct {
+	total    int
+	requests [][2]int64
+	err      error
+}
+
+func (f *fakeActivityList) fetch(start int64, limit int64) ([]ActivityListItem, error) {
+	f.requests = append(f.requests, [2]int64{start, limit})
+	if f.err != nil {
+		return nil, f.err
+	}
+	page := make([]ActivityListItem, 0)
+	for i := start; i < start+limit && i < int64(f.total); i++ {
+		page = append(page, ActivityListItem{ActivityId: i})
+	}
+	return page, nil
+}
+
+func TestActivityIterator_Pages(t *testing.T) {
+	list := &fakeActivityList{total: 250}
+
+	activityList, err := newActivityIterator(context.Background(), ActivityFilter{}, list.fetch).All()
+	assert.Nil(t, err)
+	assert.Len(t, activityList, 250)
+	for i, activity := range activityList {
+		assert.Equal(t, int64(i), activity.ActivityId)
+	}
+	// the short third page ends the iteration without an extra request
+	assert.Equal(t, [][2]int64{{0, 100}, {100, 100}, {200, 100}}, list.requests)
+}
+
+func TestActivityIterator_ExactPages(t *testing.T) {
+	list := &fakeActivityList{total: 20}
+
+	activityList, err := newActivityIterator(context.Background(), ActivityFilter{PageSize: 10}, list.fetch).All()
+	assert.Nil(t, err)
+	assert.Len(t, activityList, 20)
+	assert.Equal(t, [][2]int64{{0, 10}, {10, 10}, {20, 10}}, list.requests)
+}
+
+func TestActivityIterator_Limit(t *testing.T) {
+	list := &fakeActivityList{total: 250}
+
+	activityList, err := newActivityIterator(context.Background(), ActivityFilter{Limit: 5}, list.fetch).All()
+	assert.Nil(t, err)
+	assert.Len(t, activityList, 5)
+	assert.Equal(t, [][2]int64{{0, 5}}, list.requests)
+
+	list = &fakeActivityList{total: 250}
+	activityList, err = newActivityIterator(context.Background(), ActivityFilter{Limit: 150, PageSize: 1000}, list.fetch).All()
+	assert.Nil(t, err)
+	assert.Len(t, activityList, 150)
+	assert.Equal(t, [][2]int64{{0, 100}, {100, 50}}, list.requests)
+}
+
+func TestActivityIterator_Error(t *testing.T) {
+	list := &fakeActivityList{err: errors.New("invalid status code: 500")}
+
+	it := newActivityIterator(context.Background(), ActivityFilter{}, list.fetch)
+	assert.False(t, it.Next())
+	assert.Equal(t, list.err, it.Err())
+	assert.False(t, it.Next())
+	assert.Len(t, list.requests, 1)
+}
+
+func TestActivityIterator_Canceled(t *testing.T) {
+	list := &fakeActivityList{total: 250}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	it := newActivityIterator(ctx, ActivityFilter{}, list.fetch)
+	for i := 0; i < 100; i++ {
+		assert.True(t, it.Next())
+	}
+	cancel()
+	assert.False(t, it.Next())
+	assert.Equal(t, context.Canceled, it.Err())
+	assert.Len(t, list.requests, 1)
+}
+
+func TestActivityFilter_Params(t *testing.T) {
+	assert.Equal(t, map[string]interface{}{}, ActivityFilter{}.params())
+
+	filter := ActivityFilter{
+		StartDate:    time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:      time.Date(2021, 9, 8, 23, 0, 0, 0, time.UTC),
+		ActivityType: "running",
+	}
+	assert.Equal(t, map[string]interface{}{
+		"startDate":    "2021-01-01",
+		"endDate":      "2021-09-08",
+		"activityType": "running",
+	}, filter.params())
+}
@@ -1,10 +1,61 @@
 package garmin
 
+import "encoding/json"
+
+// ActivityListItem is an activity as returned by the activity list. Raw holds the
+// undecoded JSON, for fields not covered by the struct.
 type ActivityListItem struct {
-	ActivityId     int64  `json:"activityId"`
-	ActivityName   string `json:"activityName"`
-	StartTimeLocal string `json:"startTimeLocal"`
-	StartTimeGMT   string `json:"startTimeGMT"`
+	ActivityId       int64         `json:"activityId"`
+	ActivityName     string        `json:"activityName"`
+	Description      string        `json:"description"`
+	StartTimeLocal   string        `json:"startTimeLocal"`
+	StartTimeGMT     string        `json:"startTimeGMT"`
+	BeginTimestamp   int64         `json:"beginTimestamp"`
+	ActivityType     ActivityType  `json:"activityType"`
+	EventType        EventType     `json:"eventType"`
+	Distance         float64       `json:"distance"`
+	Duration         float64       `json:"duration"`
+	ElapsedDuration  float64       `json:"elapsedDuration"`
+	MovingDuration   float64       `json:"movingDuration"`
+	ElevationGain    float64       `json:"elevationGain"`
+	ElevationLoss    float64       `json:"elevationLoss"`
+	AverageSpeed     float64       `json:"averageSpeed"`
+	MaxSpeed         float64       `json:"maxSpeed"`
+	StartLatitude    float64       `json:"startLatitude"`
+	StartLongitude   float64       `json:"startLongitude"`
+	Calories         float64       `json:"calories"`
+	AverageHR        float64       `json:"averageHR"`
+	MaxHR            float64       `json:"maxHR"`
+	Steps            int64         `json:"steps"`
+	LocationName     string        `json:"locationName"`
+	OwnerId          int64         `json:"ownerId"`
+	OwnerDisplayName string        `json:"ownerDisplayName"`
+	DeviceId         int64         `json:"deviceId"`
+	Manufacturer     string        `json:"manufacturer"`
+	Privacy          AccessControl `json:"privacy"`
+	HasPolyline      bool          `json:"hasPolyline"`
+	Favorite         bool          `json:"favorite"`
+	Parent           bool          `json:"parent"`
+
+	Raw json.RawMessage `json:"-"`
+}
+
+type EventType struct {
+	TypeId    int    `json:"typeId"`
+	TypeKey   string `json:"typeKey"`
+	SortOrder int    `json:"sortOrder"`
+}
+
+func (a *ActivityListItem) UnmarshalJSON(data []byte) error {
+	// NOTE: the alias drops this method, otherwise json.Unmarshal would recurse
+	type activityListItem ActivityListItem
+	item := activityListItem{}
+	if err := json.Unmarshal(data, &item); err != nil {
+		return err
+	}
+	*a = ActivityListItem(item)
+	a.Raw = append(json.RawMessage(nil), data...)
+	return nil
 }
 
 func (a *ActivityListItem) Equals(obj interface{}) bool {
@@ -30,3 +81,35 @@ func (a *ActivityListItem) Equals(obj interface{}) bool {
 
 	return false
 }
+
+// Activity returns the metadata of the listed activity as far as the list
+// carries it, for callers which do not need the full details of GetActivity.
+func (a ActivityListItem) Activity() Activity {
+	return Activity{
+		ActivityId:         a.ActivityId,
+		ActivityName:       a.ActivityName,
+		Description:        a.Description,
+		UserProfileId:      int(a.OwnerId),
+		IsMultiSportParent: a.Parent,
+		ActivityType:       a.ActivityType,
+		Summary: Summary{
+			StartTimeLocal:  a.StartTimeLocal,
+			StartTimeGMT:    a.StartTimeGMT,
+			StartLatitude:   a.StartLatitude,
+			StartLongitude:  a.StartLongitude,
+			Distance:        a.Distance,
+			Duration:        a.Duration,
+			MovingDuration:  a.MovingDuration,
+			ElapsedDuration: a.ElapsedDuration,
+			ElevationGain:   a.ElevationGain,
+			ElevationLoss:   a.ElevationLoss,
+			AverageSpeed:    a.AverageSpeed,
+			MaxSpeed:        a.MaxSpeed,
+			Calories:        a.Calories,
+			AverageHR:       a.AverageHR,
+			MaxHR:           a.MaxHR,
+		},
+		MetadataDTO:       MetaData{Manufacturer: a.Manufacturer},
+		AccessControlRule: a.Privacy,
+	}
+}
@@ -0,0 +1,111 @@
+package garmin
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+const activityListJson = `[{
+	"activityId": 7654321,
+	"activityName": "Shanghai Running",
+	"description": null,
+	"startTimeLocal": "2021-09-08 09:46:40",
+	"startTimeGMT": "2021-09-08 01:46:40",
+	"activityType": {"typeId": 1, "typeKey": "running", "parentTypeId": 17, "isHidden": false, "sortOrder": 3},
+	"eventType": {"typeId": 9, "typeKey": "uncategorized", "sortOrder": 10},
+	"distance": 5012.3,
+	"duration": 1805.2,
+	"elapsedDuration": 1830.0,
+	"movingDuration": 1790.0,
+	"elevationGain": 12.0,
+	"elevationLoss": 11.0,
+	"averageSpeed": 2.77,
+	"maxSpeed": 3.9,
+	"startLatitude": 31.2304,
+	"startLongitude": 121.4737,
+	"calories": 380.0,
+	"averageHR": 151.0,
+	"maxHR": 172.0,
+	"steps": 5120,
+	"locationName": "Shanghai",
+	"ownerId": 12345,
+	"ownerDisplayName": "runner",
+	"deviceId": 3999999999,
+	"manufacturer": "GARMIN",
+	"privacy": {"typeId": 2, "typeKey": "private"},
+	"hasPolyline": true,
+	"favorite": false,
+	"parent": false,
+	"vO2MaxValue": 52.0
+}]`
+
+func TestActivityListItem_UnmarshalJSON(t *testing.T) {
+	activityList := make([]ActivityListItem, 0)
+	assert.Nil(t, json.Unmarshal([]byte(activityListJson), &activityList))
+	assert.Len(t, activityList, 1)
+
+	item := activityList[0]
+	assert.Equal(t, int64(7654321), item.ActivityId)
+	assert.Equal(t, "", item.Description)
+	assert.Equal(t, "running", item.ActivityType.TypeKey)
+	assert.Equal(t, "uncategorized", item.EventType.TypeKey)
+	assert.InDelta(t, 5012.3, item.Distance, 1e-9)
+	assert.InDelta(t, 1805.2, item.Duration, 1e-9)
+	assert.Equal(t, int64(5120), item.Steps)
+	assert.Equal(t, int64(3999999999), item.DeviceId)
+	assert.Equal(t, "GARMIN", item.Manufacturer)
+	assert.Equal(t, "private", item.Privacy.TypeKey)
+	assert.True(t, item.HasPolyline)
+
+	raw := make(map[string]interface{})
+	assert.Nil(t, json.Unmarshal(item.Raw, &raw))
+	assert.Equal(t, 52.0, raw["vO2MaxValue"])
+}
+
+func TestActivity_ListItem(t *testing.T) {
+	activity := Activity{
+		ActivityId:        7654321,
+		ActivityName:      "Shanghai Running",
+		ActivityType:      ActivityType{TypeKey: "running"},
+		Summary:           Summary{StartTimeGMT: "2021-09-08T01:46:40.0", Duration: 1805.2, Distance: 5012.3},
+		MetadataDTO:       MetaData{Manufacturer: "GARMIN"},
+		AccessControlRule: AccessControl{TypeKey: "private"},
+	}
+
+	item := activity.ListItem()
+	assert.Equal(t, activity.ActivityId, item.ActivityId)
+	assert.Equal(t, "running", item.ActivityType.TypeKey)
+	assert.Equal(t, "2021-09-08T01:46:40.0", item.StartTimeGMT)
+	assert.InDelta(t, 1805.2, item.Duration, 1e-9)
+	assert.Equal(t, "GARMIN", item.Manufacturer)
+	assert.Equal(t, "private", item.Privacy.TypeKey)
+}
+
+func TestActivityListItem_Activity(t *testing.T) {
+	item := ActivityListItem{
+		ActivityId:     7654321,
+		ActivityName:   "Shanghai Running",
+		StartTimeLocal: "2021-09-08 09:46:40",
+		StartTimeGMT:   "2021-09-08 01:46:40",
+		ActivityType:   ActivityType{TypeKey: "running"},
+		Distance:       5012.3,
+		StartLatitude:  31.2,
+		Manufacturer:   "GARMIN",
+		Privacy:        AccessControl{TypeKey: "private"},
+	}
+
+	activity := item.Activity()
+	assert.Equal(t, item.ActivityId, activity.ActivityId)
+	assert.Equal(t, "Shanghai Running", activity.ActivityName)
+	assert.Equal(t, "2021-09-08 09:46:40", activity.Summary.StartTimeLocal)
+	assert.InDelta(t, 5012.3, activity.Summary.Distance, 1e-9)
+	assert.InDelta(t, 31.2, activity.Summary.StartLatitude, 1e-9)
+	assert.Equal(t, "GARMIN", activity.MetadataDTO.Manufacturer)
+
+	// the conversion is lossless for the fields the list carries
+	back := activity.ListItem()
+	assert.Equal(t, item.ActivityType, back.ActivityType)
+	assert.Equal(t, item.StartTimeGMT, back.StartTimeGMT)
+	assert.Equal(t, item.Privacy, back.Privacy)
+}
@@ -1,17 +1,20 @@
 package garmin
 
 import (
-	"archive/zip"
 	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/sirupsen/logrus"
 	"github.com/yqt/garmin-intl2cn/util"
 	"io"
+	"io/ioutil"
+	"net/http"
 	"net/url"
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 )
 
 type UserInfo struct {
@@ -27,6 +30,8 @@ const (
 	UserAgent        = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/90.0.4430.212 Safari/537.36"
 )
 
+var ErrDuplicateActivity = errors.New("duplicate activity")
+
 type Client struct {
 	Email     string `json:"email"`
 	Password  string `json:"password"`
@@ -36,6 +41,12 @@ type Client struct {
 
 	client   *util.CookieRequest
 	loggedIn bool
+
+	cacheMu sync.Mutex
+	// loginProfile is the social profile embedded in the login page, if it could be parsed
+	loginProfile *socialProfile
+	profile      *Profile
+	devices      []Device
 }
 
 type Option func(client *Client)
@@ -55,11 +66,25 @@ func SetEnv(apiHost string, ssoPrefix string) Option {
 	}
 }
 
+func RateLimit(limit util.RateLimit) Option {
+	return func(c *Client) {
+		c.client.SetRateLimit(limit)
+	}
+}
+
+func Retry(policy util.RetryPolicy) Option {
+	return func(c *Client) {
+		c.client.SetRetryPolicy(policy)
+	}
+}
+
 func NewClient(options ...Option) *Client {
 	client := &Client{
 		client:   util.NewCookieRequest(),
 		loggedIn: false,
 	}
+	client.client.SetRateLimit(util.DefaultRateLimit)
+	client.client.SetRetryPolicy(util.DefaultRetryPolicy)
 
 	client.SetOptions(options...)
 
@@ -146,13 +171,26 @@ func (c *Client) Auth(reLogin bool) error {
 		"checkSocialProfileExisted": true,
 	}).Debug()
 
+	loginProfile, err := c.parseSocialProfile(respText)
+	if err != nil {
+		// NOTE: Profile falls back to the social profile API
+		logrus.WithFields(logrus.Fields{
+			"err": err,
+		}).Debug("embedded social profile not parsed")
+	}
+	c.cacheMu.Lock()
+	c.loginProfile = loginProfile
+	c.profile = nil
+	c.devices = nil
+	c.cacheMu.Unlock()
+
 	c.loggedIn = true
 
 	return nil
 }
 
 func (c *Client) GetActivity(id int64) (Activity, error) {
-	uri := c.ApiPrefix + "/proxy/activity-service/activity/" + strconv.FormatInt(id, 10)
+	uri := c.activityUri(id, "")
 	activity := Activity{}
 	err := c.client.GetJson(uri, nil, &activity)
 	if err != nil {
@@ -162,13 +200,75 @@ func (c *Client) GetActivity(id int64) (Activity, error) {
 	return activity, nil
 }
 
+func (c *Client) GetActivitySplits(id int64) (ActivitySplits, error) {
+	splits := ActivitySplits{}
+	err := c.client.GetJson(c.activityUri(id, "/splits"), nil, &splits)
+	return splits, err
+}
+
+// GetActivityDetails returns the time series of an activity, downsampled by garmin to at most maxChartSize samples.
+func (c *Client) GetActivityDetails(id int64, maxChartSize int) (ActivityDetails, error) {
+	details := ActivityDetails{}
+	params := map[string]interface{}{
+		"maxChartSize":    maxChartSize,
+		"maxPolylineSize": 0,
+	}
+	err := c.client.GetJson(c.activityUri(id, "/details"), params, &details)
+	return details, err
+}
+
+func (c *Client) GetActivityHRTimeInZones(id int64) ([]TimeInZone, error) {
+	zones := make([]TimeInZone, 0)
+	err := c.client.GetJson(c.activityUri(id, "/hrTimeInZones"), nil, &zones)
+	return zones, err
+}
+
+func (c *Client) GetActivityPowerTimeInZones(id int64) ([]TimeInZone, error) {
+	zones := make([]TimeInZone, 0)
+	err := c.client.GetJson(c.activityUri(id, "/powerTimeInZones"), nil, &zones)
+	return zones, err
+}
+
+// GetActivityWeather returns nil for activities without weather, e.g. indoor ones.
+func (c *Client) GetActivityWeather(id int64) (*Weather, error) {
+	respText, err := c.client.Get(c.activityUri(id, "/weather"), nil)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(respText) == "" {
+		return nil, nil
+	}
+	weather := &Weather{}
+	if err := json.Unmarshal([]byte(respText), weather); err != nil {
+		return nil, err
+	}
+	return weather, nil
+}
+
+func (c *Client) GetActivityExerciseSets(id int64) (ExerciseSets, error) {
+	sets := ExerciseSets{}
+	err := c.client.GetJson(c.activityUri(id, "/exerciseSets"), nil, &sets)
+	return sets, err
+}
+
+func (c *Client) activityUri(id int64, path string) string {
+	return c.ApiPrefix + "/proxy/activity-service/activity/" + strconv.FormatInt(id, 10) + path
+}
+
 func (c *Client) GetActivityList(start int64, limit int64) ([]ActivityListItem, error) {
+	return c.searchActivities(start, limit, nil)
+}
+
+func (c *Client) searchActivities(start int64, limit int64, filterParams map[string]interface{}) ([]ActivityListItem, error) {
 	uri := c.ApiPrefix + "/proxy/activitylist-service/activities/search/activities"
 	activityList := make([]ActivityListItem, 0)
 	params := map[string]interface{}{
 		"start": start,
 		"limit": limit,
 	}
+	for key, val := range filterParams {
+		params[key] = val
+	}
 	err := c.client.GetJson(uri, params, &activityList)
 	if err != nil {
 		return activityList, err
@@ -177,30 +277,65 @@ func (c *Client) GetActivityList(start int64, limit int64) ([]ActivityListItem,
 	return activityList, nil
 }
 
-func (c *Client) DownloadActivity(id int64) (io.ReadCloser, string, error) {
-	uri := c.ApiPrefix + "/modern/proxy/download-service/files/activity/" + strconv.FormatInt(id, 10)
+// DownloadActivity returns the files of an activity in the given format. The
+// original download may hold several files, every other format holds exactly one.
+func (c *Client) DownloadActivity(id int64, format Format) ([]ActivityFile, error) {
+	idStr := strconv.FormatInt(id, 10)
 
-	contentBytes, err := c.client.GetFile(uri, nil)
+	if format == FormatOriginal {
+		uri := c.ApiPrefix + "/modern/proxy/download-service/files/activity/" + idStr
+		contentBytes, err := c.client.GetFile(uri, nil)
+		if err != nil {
+			return nil, err
+		}
+		files, err := unzipActivityFiles(contentBytes)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			logrus.Debug("Reading file:", file.FileName)
+		}
+		return files, nil
+	}
 
-	zipReader, err := zip.NewReader(bytes.NewReader(contentBytes), int64(len(contentBytes)))
+	if _, ok := formatContentTypes[format]; !ok || format == FormatFIT {
+		return nil, fmt.Errorf("unsupported download format: %s", format)
+	}
+	uri := c.ApiPrefix + "/modern/proxy/download-service/export/" + string(format) + "/activity/" + idStr
+	contentBytes, fileName, err := c.client.GetFileWithName(uri, nil)
 	if err != nil {
-		return nil, "", err
+		return nil, err
 	}
+	if fileName == "" {
+		fileName = idStr + "." + string(format)
+	}
+	file := NewActivityFile(fileName, contentBytes)
+	// NOTE: trust the requested format over whatever extension the server suggested
+	file.Format = format
+	file.ContentType = formatContentTypes[format]
 
-	for _, zipFile := range zipReader.File {
-		logrus.Debug("Reading file:", zipFile.Name)
-		file, err := zipFile.Open()
-		if err != nil {
-			return nil, "", err
-		}
-		return file, zipFile.Name, err
+	return []ActivityFile{file}, nil
+}
+
+// UploadActivity uploads a FIT, TCX or GPX file, detecting its format from the content.
+// It returns the id of the created activity, or 0 if garmin is still processing the file.
+func (c *Client) UploadActivity(fileName string, file io.ReadCloser) (int64, error) {
+	defer file.Close()
+	data, err := ioutil.ReadAll(file)
+	if err != nil {
+		return 0, err
 	}
 
-	return nil, "", errors.New("not file in zip file")
+	return c.UploadActivityAs(fileName, ioutil.NopCloser(bytes.NewReader(data)), DetectFormat(fileName, data))
 }
 
-func (c *Client) UploadActivity(fileName string, file io.ReadCloser) error {
-	uri := c.ApiPrefix + "/modern/proxy/upload-service/upload/.fit"
+func (c *Client) UploadActivityAs(fileName string, file io.ReadCloser, format Format) (int64, error) {
+	if !uploadFormats[format] {
+		_ = file.Close()
+		return 0, fmt.Errorf("unsupported upload format: %q", format)
+	}
+	uri := c.ApiPrefix + "/modern/proxy/upload-service/upload/." + string(format)
+	fileName = fileNameWithFormat(fileName, format)
 
 	headers := map[string]string{
 		"Origin":  c.ApiPrefix,
@@ -209,16 +344,56 @@ func (c *Client) UploadActivity(fileName string, file io.ReadCloser) error {
 	}
 	c.client.UpdateHeaders(headers)
 
+	// NOTE: uploads are not retried, a retry after a lost response would get a 409 and
+	// report the stored activity as a duplicate, which is then never renamed nor linked.
+	// Failed uploads are left to the retry queue instead.
 	respText, err := c.client.UploadFile(uri, nil, "file", fileName, file)
 	if err != nil {
-		return err
+		var statusErr *util.StatusError
+		if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusConflict {
+			return 0, ErrDuplicateActivity
+		}
+		return 0, err
 	}
 
 	logrus.WithFields(logrus.Fields{
 		"uploadRespText": respText,
 	}).Info()
 
-	return nil
+	return uploadedActivityId(respText), nil
+}
+
+type uploadResponse struct {
+	DetailedImportResult struct {
+		Successes []struct {
+			InternalId int64 `json:"internalId"`
+		} `json:"successes"`
+	} `json:"detailedImportResult"`
+}
+
+// uploadedActivityId returns 0 when the response holds no created activity, as for
+// a 202 Accepted upload that is processed asynchronously.
+func uploadedActivityId(respText string) int64 {
+	resp := uploadResponse{}
+	if err := json.Unmarshal([]byte(respText), &resp); err != nil {
+		return 0
+	}
+	for _, success := range resp.DetailedImportResult.Successes {
+		if success.InternalId != 0 {
+			return success.InternalId
+		}
+	}
+	return 0
+}
+
+func (c *Client) UpdateActivityName(id int64, name string) error {
+	uri := c.activityUri(id, "")
+	data := map[string]interface{}{
+		"activityId":   id,
+		"activityName": name,
+	}
+	_, err := c.client.Put(uri, nil, data, nil, true)
+	return err
 }
 
 func (c *Client) extractCSRFToken(respText string) (string, error) {
@@ -5,8 +5,15 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/yqt/garmin-intl2cn/config"
+	"github.com/yqt/garmin-intl2cn/util"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 var (
@@ -52,6 +59,70 @@ func TestClient_GetActivity(t *testing.T) {
 	}).Info()
 }
 
+// newFixtureServer answers the activity service paths with the recorded responses of testdata.
+func newFixtureServer(t *testing.T, fixtures map[string]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name, ok := fixtures[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		data, err := ioutil.ReadFile(filepath.Join("testdata", name))
+		assert.Nil(t, err)
+		_, _ = w.Write(data)
+	}))
+}
+
+func TestClient_GetActivityDetails(t *testing.T) {
+	prefix := "/proxy/activity-service/activity/7654321"
+	server := newFixtureServer(t, map[string]string{
+		prefix + "/splits":        "activity_splits.json",
+		prefix + "/details":       "activity_details.json",
+		prefix + "/hrTimeInZones": "activity_hr_zones.json",
+		prefix + "/weather":       "activity_weather.json",
+	})
+	defer server.Close()
+
+	client := NewClient(RateLimit(util.RateLimit{}))
+	client.ApiPrefix = server.URL
+
+	splits, err := client.GetActivitySplits(7654321)
+	assert.Nil(t, err)
+	assert.Len(t, splits.Laps, 2)
+	assert.Equal(t, 2, splits.Laps[1].LapIndex)
+	assert.Equal(t, "2021-09-08T01:52:32.0", splits.Laps[1].StartTimeGMT)
+	assert.InDelta(t, 512.3, splits.Laps[1].Distance, 1e-9)
+	assert.InDelta(t, 172.0, splits.Laps[1].AverageCadence, 1e-9)
+
+	details, err := client.GetActivityDetails(7654321, 100)
+	assert.Nil(t, err)
+	assert.Equal(t, 6, details.MeasurementCount)
+	assert.Equal(t, 4, details.MetricsCount)
+	assert.Len(t, details.Metrics, 4)
+	heartRate := details.Metric("directHeartRate")
+	assert.Equal(t, 142.0, *heartRate[3])
+	cadence := details.Metric("directRunCadence")
+	assert.Nil(t, cadence[0])
+	assert.Equal(t, 170.0, *cadence[2])
+
+	hrZones, err := client.GetActivityHRTimeInZones(7654321)
+	assert.Nil(t, err)
+	assert.Len(t, hrZones, 5)
+	assert.Equal(t, 3, hrZones[2].ZoneNumber)
+	assert.InDelta(t, 281.617, hrZones[2].SecsInZone, 1e-9)
+	assert.Equal(t, 133.0, hrZones[2].ZoneLowBoundary)
+
+	weather, err := client.GetActivityWeather(7654321)
+	assert.Nil(t, err)
+	assert.Equal(t, 79.0, weather.Temp)
+	assert.Equal(t, 0.0, weather.WindGust)
+	assert.Equal(t, "ZSSS", weather.WeatherStation.Id)
+	assert.Equal(t, "Partly Cloudy", weather.WeatherType.Desc)
+
+	_, err = client.GetActivityExerciseSets(7654321)
+	assert.NotNil(t, err)
+}
+
 func TestClient_GetActivityList(t *testing.T) {
 	logrus.SetOutput(os.Stdout)
 	logrus.SetLevel(logrus.DebugLevel)
@@ -87,11 +158,17 @@ func TestClient_DownloadActivity(t *testing.T) {
 	err := client.Auth(false)
 	assert.Nil(t, err)
 
-	_, fileName, err := client.DownloadActivity(123456)
-	assert.Nil(t, err)
-	logrus.WithFields(logrus.Fields{
-		"fileName": fileName,
-	}).Info()
+	for _, format := range []Format{FormatOriginal, FormatTCX, FormatGPX, FormatKML, FormatCSV} {
+		files, err := client.DownloadActivity(123456, format)
+		assert.Nil(t, err)
+		for _, file := range files {
+			logrus.WithFields(logrus.Fields{
+				"format":      format,
+				"fileName":    file.FileName,
+				"contentType": file.ContentType,
+			}).Info()
+		}
+	}
 }
 
 func TestClient_UploadActivity(t *testing.T) {
@@ -106,7 +183,7 @@ func TestClient_UploadActivity(t *testing.T) {
 	err := client.Auth(false)
 	assert.Nil(t, err)
 
-	file, fileName, err := client.DownloadActivity(123456)
+	files, err := client.DownloadActivity(123456, FormatOriginal)
 	assert.Nil(t, err)
 
 	clientCn := NewClient(
@@ -116,6 +193,43 @@ func TestClient_UploadActivity(t *testing.T) {
 	err = clientCn.Auth(false)
 	assert.Nil(t, err)
 
-	err = clientCn.UploadActivity(fileName, file)
-	assert.Nil(t, err)
+	for _, file := range files {
+		id, err := clientCn.UploadActivity(file.FileName, file.Reader())
+		assert.Nil(t, err)
+		logrus.WithFields(logrus.Fields{
+			"activityId": id,
+		}).Info()
+	}
+}
+
+func TestUploadedActivityId(t *testing.T) {
+	created := `{"detailedImportResult":{"uploadId":1,"successes":[{"internalId":7654321,"externalId":null}],"failures":[]}}`
+	assert.Equal(t, int64(7654321), uploadedActivityId(created))
+
+	accepted := `{"detailedImportResult":{"uploadId":1,"successes":[],"failures":[]}}`
+	assert.Equal(t, int64(0), uploadedActivityId(accepted))
+
+	assert.Equal(t, int64(0), uploadedActivityId(""))
+}
+
+func TestClient_UploadActivityNotRetried(t *testing.T) {
+	calls := 0
+	status := http.StatusServiceUnavailable
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	client := NewClient(RateLimit(util.RateLimit{}), Retry(util.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}))
+	client.ApiPrefix = server.URL
+
+	_, err := client.UploadActivity("activity.gpx", ioutil.NopCloser(strings.NewReader(`<gpx></gpx>`)))
+	assert.NotNil(t, err)
+	assert.Equal(t, 1, calls)
+
+	status = http.StatusConflict
+	_, err = client.UploadActivity("activity.gpx", ioutil.NopCloser(strings.NewReader(`<gpx></gpx>`)))
+	assert.Equal(t, ErrDuplicateActivity, err)
+	assert.Equal(t, 2, calls)
 }
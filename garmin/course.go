@@ -0,0 +1,126 @@
+package garmin
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Course is a planned route of the course-service. Raw holds the whole course,
+// including its geo points.
+type Course struct {
+	CourseId              int64        `json:"courseId"`
+	CourseName            string       `json:"courseName"`
+	Description           string       `json:"description"`
+	ActivityType          ActivityType `json:"activityType"`
+	DistanceInMeters      float64      `json:"distanceInMeters"`
+	ElevationGainInMeters float64      `json:"elevationGainInMeters"`
+	ElevationLossInMeters float64      `json:"elevationLossInMeters"`
+	CreateDate            string       `json:"createDate"`
+	UpdateDate            string       `json:"updateDate"`
+
+	Raw json.RawMessage `json:"-"`
+}
+
+func (c *Course) UnmarshalJSON(data []byte) error {
+	type course Course
+	item := course{}
+	if err := json.Unmarshal(data, &item); err != nil {
+		return err
+	}
+	*c = Course(item)
+	c.Raw = append(json.RawMessage(nil), data...)
+	return nil
+}
+
+// courseServerFields are assigned by garmin and must not be sent when creating a course.
+var courseServerFields = []string{"courseId", "userProfilePk", "userProfileId", "createDate", "updateDate", "uploadId", "virtualPartnerId"}
+
+func (c *Client) GetCourses() ([]Course, error) {
+	uri := c.ApiPrefix + "/proxy/course-service/course"
+	courses := make([]Course, 0)
+	err := c.client.GetJson(uri, nil, &courses)
+	return courses, err
+}
+
+func (c *Client) GetCourse(id int64) (Course, error) {
+	uri := c.ApiPrefix + "/proxy/course-service/course/" + strconv.FormatInt(id, 10)
+	course := Course{}
+	err := c.client.GetJson(uri, nil, &course)
+	return course, err
+}
+
+// DownloadCourse returns a course as a FIT or GPX file.
+func (c *Client) DownloadCourse(id int64, format Format) (ActivityFile, error) {
+	idStr := strconv.FormatInt(id, 10)
+	var uri string
+	switch format {
+	case FormatGPX:
+		uri = c.ApiPrefix + "/proxy/course-service/course/gpx/" + idStr
+	case FormatFIT:
+		uri = c.ApiPrefix + "/proxy/course-service/course/fit/" + idStr + "/0"
+	default:
+		return ActivityFile{}, fmt.Errorf("unsupported course format: %s", format)
+	}
+
+	data, fileName, err := c.client.GetFileWithName(uri, nil)
+	if err != nil {
+		return ActivityFile{}, err
+	}
+	if fileName == "" {
+		fileName = "course_" + idStr + "." + string(format)
+	}
+	file := NewActivityFile(fileName, data)
+	file.Format = format
+	file.ContentType = formatContentTypes[format]
+	return file, nil
+}
+
+// ImportCourse parses a GPX or FIT file into a course, which is not saved until passed to CreateCourse.
+func (c *Client) ImportCourse(fileName string, file io.ReadCloser) (Course, error) {
+	uri := c.ApiPrefix + "/proxy/course-service/course/import"
+	headers := map[string]string{
+		"Origin": c.ApiPrefix,
+		"Nk":     "NT",
+	}
+	respText, err := c.client.UploadFileWithHeaders(uri, nil, "file", fileName, file, headers)
+	if err != nil {
+		return Course{}, err
+	}
+	course := Course{}
+	err = json.Unmarshal([]byte(respText), &course)
+	return course, err
+}
+
+// CreateCourse saves course, e.g. as returned by ImportCourse or GetCourse, to the logged in account.
+func (c *Client) CreateCourse(course Course) (Course, error) {
+	data, err := courseCreatePayload(course)
+	if err != nil {
+		return Course{}, err
+	}
+	uri := c.ApiPrefix + "/proxy/course-service/course"
+	created := Course{}
+	err = c.client.PostJson(uri, nil, data, nil, true, &created)
+	return created, err
+}
+
+// courseCreatePayload sends the decoded name, description and type, so callers can change them.
+func courseCreatePayload(course Course) (map[string]interface{}, error) {
+	data := make(map[string]interface{})
+	if len(course.Raw) > 0 {
+		if err := json.Unmarshal(course.Raw, &data); err != nil {
+			return nil, err
+		}
+	}
+	for _, field := range courseServerFields {
+		delete(data, field)
+	}
+	data["courseName"] = course.CourseName
+	data["description"] = course.Description
+	if course.ActivityType.TypeId != 0 {
+		data["activityTypePk"] = course.ActivityType.TypeId
+		data["activityType"] = course.ActivityType
+	}
+	return data, nil
+}
@@ -0,0 +1,42 @@
+package garmin
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCourseCreatePayload(t *testing.T) {
+	imported := Course{}
+	assert.Nil(t, json.Unmarshal([]byte(`{
+		"courseId": null,
+		"userProfilePk": 54321,
+		"courseName": "Imported Course",
+		"activityTypePk": 1,
+		"distanceInMeters": 10021.5,
+		"geoPoints": [{"latitude": 31.2304, "longitude": 121.4737, "elevation": 12.0}]
+	}`), &imported))
+	assert.InDelta(t, 10021.5, imported.DistanceInMeters, 1e-9)
+
+	imported.CourseName = "Bund Loop"
+	imported.Description = "along the river"
+	imported.ActivityType = ActivityType{TypeId: 2, TypeKey: "cycling"}
+	data, err := courseCreatePayload(imported)
+	assert.Nil(t, err)
+
+	_, ok := data["userProfilePk"]
+	assert.False(t, ok)
+	_, ok = data["courseId"]
+	assert.False(t, ok)
+	assert.Equal(t, "Bund Loop", data["courseName"])
+	assert.Equal(t, "along the river", data["description"])
+	assert.Equal(t, 2, data["activityTypePk"])
+	assert.Len(t, data["geoPoints"], 1)
+}
+
+func TestDownloadCourse_UnsupportedFormat(t *testing.T) {
+	client := NewClient(SetEnv(ApiServiceHost, SsoPrefix))
+
+	_, err := client.DownloadCourse(123456, FormatTCX)
+	assert.NotNil(t, err)
+}
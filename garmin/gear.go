@@ -0,0 +1,93 @@
+package garmin
+
+import (
+	"strconv"
+	"strings"
+)
+
+type Gear struct {
+	Uuid            string  `json:"uuid"`
+	GearPk          int64   `json:"gearPk"`
+	UserProfilePk   int64   `json:"userProfilePk"`
+	GearMakeName    string  `json:"gearMakeName"`
+	GearModelName   string  `json:"gearModelName"`
+	GearTypeName    string  `json:"gearTypeName"`
+	GearStatusName  string  `json:"gearStatusName"`
+	DisplayName     string  `json:"displayName"`
+	CustomMakeModel string  `json:"customMakeModel"`
+	DateBegin       string  `json:"dateBegin"`
+	DateEnd         string  `json:"dateEnd"`
+	MaximumMeters   float64 `json:"maximumMeters"`
+}
+
+// Name is how the gear is shown on garmin connect.
+func (g Gear) Name() string {
+	if g.DisplayName != "" {
+		return g.DisplayName
+	}
+	if g.CustomMakeModel != "" {
+		return g.CustomMakeModel
+	}
+	return strings.TrimSpace(g.GearMakeName + " " + g.GearModelName)
+}
+
+// GetGear returns all gear of the logged in account, retired ones included.
+func (c *Client) GetGear() ([]Gear, error) {
+	profile, err := c.Profile()
+	if err != nil {
+		return nil, err
+	}
+	uri := c.ApiPrefix + "/proxy/gear-service/gear/filterGear"
+	params := map[string]interface{}{
+		"userProfilePk": profile.UserProfilePk,
+	}
+	gear := make([]Gear, 0)
+	err = c.client.GetJson(uri, params, &gear)
+	return gear, err
+}
+
+// GetActivityGear returns the gear linked to an activity.
+func (c *Client) GetActivityGear(activityId int64) ([]Gear, error) {
+	uri := c.ApiPrefix + "/proxy/gear-service/gear/filterGear"
+	params := map[string]interface{}{
+		"activityId": activityId,
+	}
+	gear := make([]Gear, 0)
+	err := c.client.GetJson(uri, params, &gear)
+	return gear, err
+}
+
+// CreateGear creates a copy of gear, e.g. of another account, owned by the logged in account.
+func (c *Client) CreateGear(gear Gear) (Gear, error) {
+	profile, err := c.Profile()
+	if err != nil {
+		return Gear{}, err
+	}
+	uri := c.ApiPrefix + "/proxy/gear-service/gear"
+	created := Gear{}
+	err = c.client.PostJson(uri, nil, gearCreatePayload(gear, profile.UserProfilePk), nil, true, &created)
+	return created, err
+}
+
+// gearCreatePayload leaves out the uuid and gearPk of the source account.
+func gearCreatePayload(gear Gear, userProfilePk int64) map[string]interface{} {
+	return map[string]interface{}{
+		"userProfilePk":   userProfilePk,
+		"gearMakeName":    gear.GearMakeName,
+		"gearModelName":   gear.GearModelName,
+		"gearTypeName":    gear.GearTypeName,
+		"gearStatusName":  gear.GearStatusName,
+		"displayName":     gear.DisplayName,
+		"customMakeModel": gear.CustomMakeModel,
+		"dateBegin":       gear.DateBegin,
+		"dateEnd":         gear.DateEnd,
+		"maximumMeters":   gear.MaximumMeters,
+	}
+}
+
+// LinkGear adds the gear to the equipment used in an activity.
+func (c *Client) LinkGear(gearUuid string, activityId int64) error {
+	uri := c.ApiPrefix + "/proxy/gear-service/gear/link/" + gearUuid + "/activity/" + strconv.FormatInt(activityId, 10)
+	_, err := c.client.Put(uri, nil, nil, nil, false)
+	return err
+}
@@ -0,0 +1,39 @@
+package garmin
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestGear_Name(t *testing.T) {
+	assert.Equal(t, "Daily Trainers", Gear{DisplayName: "Daily Trainers", GearMakeName: "Asics"}.Name())
+	assert.Equal(t, "Custom Bike", Gear{CustomMakeModel: "Custom Bike", GearMakeName: "Other"}.Name())
+	assert.Equal(t, "Asics Novablast 3", Gear{GearMakeName: "Asics", GearModelName: "Novablast 3"}.Name())
+}
+
+func TestGearCreatePayload(t *testing.T) {
+	gear := Gear{}
+	assert.Nil(t, json.Unmarshal([]byte(`{
+		"uuid": "a5d3b8c0e2f14d7a9b6c1e0f2d3a4b5c",
+		"gearPk": 11223344,
+		"userProfilePk": 54321,
+		"gearMakeName": "Asics",
+		"gearModelName": "Novablast 3",
+		"gearTypeName": "Shoes",
+		"gearStatusName": "active",
+		"displayName": "Daily Trainers",
+		"dateBegin": "2023-03-01T00:00:00.0",
+		"maximumMeters": 800000
+	}`), &gear))
+
+	data := gearCreatePayload(gear, 12345)
+	assert.Equal(t, int64(12345), data["userProfilePk"])
+	assert.Equal(t, "Daily Trainers", data["displayName"])
+	assert.Equal(t, "Shoes", data["gearTypeName"])
+	assert.Equal(t, 800000.0, data["maximumMeters"])
+	_, ok := data["uuid"]
+	assert.False(t, ok)
+	_, ok = data["gearPk"]
+	assert.False(t, ok)
+}
@@ -0,0 +1,119 @@
+package garmin
+
+import (
+	"encoding/json"
+)
+
+// Profile describes the logged in user, from the social profile and the user settings.
+type Profile struct {
+	Id            int64  `json:"id"`
+	UserProfilePk int64  `json:"profileId"`
+	DisplayName   string `json:"displayName"`
+	UserName      string `json:"userName"`
+	FullName      string `json:"fullName"`
+	Location      string `json:"location"`
+
+	MeasurementSystem string `json:"measurementSystem"`
+	TimeZone          string `json:"timeZone"`
+	TimeFormat        string `json:"timeFormat"`
+	Locale            string `json:"preferredLocale"`
+}
+
+type socialProfile struct {
+	Id          int64  `json:"id"`
+	ProfileId   int64  `json:"profileId"`
+	DisplayName string `json:"displayName"`
+	UserName    string `json:"userName"`
+	FullName    string `json:"fullName"`
+	Location    string `json:"location"`
+}
+
+type userSettings struct {
+	MeasurementSystem string `json:"measurementSystem"`
+	TimeZone          string `json:"timeZone"`
+	TimeFormat        string `json:"timeFormat"`
+	PreferredLocale   string `json:"preferredLocale"`
+}
+
+// Device is a device registered to the logged in user.
+type Device struct {
+	DeviceId               int64  `json:"deviceId"`
+	UnitId                 int64  `json:"unitId"`
+	DisplayName            string `json:"displayName"`
+	ProductDisplayName     string `json:"productDisplayName"`
+	SerialNumber           string `json:"serialNumber"`
+	PartNumber             string `json:"partNumber"`
+	CurrentFirmwareVersion string `json:"currentFirmwareVersion"`
+	Primary                bool   `json:"primaryActivityTrackerIndicator"`
+}
+
+// Profile returns the profile of the logged in user. It is fetched once per login.
+func (c *Client) Profile() (Profile, error) {
+	c.cacheMu.Lock()
+	defer c.cacheMu.Unlock()
+
+	if c.profile != nil {
+		return *c.profile, nil
+	}
+
+	social := c.loginProfile
+	if social == nil {
+		social = &socialProfile{}
+		if err := c.client.GetJson(c.ApiPrefix+"/proxy/userprofile-service/socialProfile", nil, social); err != nil {
+			return Profile{}, err
+		}
+	}
+	settings := userSettings{}
+	if err := c.client.GetJson(c.ApiPrefix+"/proxy/userprofile-service/userprofile/settings", nil, &settings); err != nil {
+		return Profile{}, err
+	}
+
+	profile := newProfile(*social, settings)
+	c.profile = &profile
+	return profile, nil
+}
+
+// Devices returns the devices registered to the logged in user. They are fetched once per login.
+func (c *Client) Devices() ([]Device, error) {
+	c.cacheMu.Lock()
+	defer c.cacheMu.Unlock()
+
+	if c.devices != nil {
+		return c.devices, nil
+	}
+	devices := make([]Device, 0)
+	err := c.client.GetJson(c.ApiPrefix+"/proxy/device-service/deviceregistration/devices", nil, &devices)
+	if err != nil {
+		return nil, err
+	}
+	c.devices = devices
+	return devices, nil
+}
+
+func newProfile(social socialProfile, settings userSettings) Profile {
+	return Profile{
+		Id:                social.Id,
+		UserProfilePk:     social.ProfileId,
+		DisplayName:       social.DisplayName,
+		UserName:          social.UserName,
+		FullName:          social.FullName,
+		Location:          social.Location,
+		MeasurementSystem: settings.MeasurementSystem,
+		TimeZone:          settings.TimeZone,
+		TimeFormat:        settings.TimeFormat,
+		Locale:            settings.PreferredLocale,
+	}
+}
+
+// parseSocialProfile decodes the social profile embedded in the page returned by the login ticket.
+func (c *Client) parseSocialProfile(respText string) (*socialProfile, error) {
+	text, err := c.extractSocialProfile(respText)
+	if err != nil {
+		return nil, err
+	}
+	profile := &socialProfile{}
+	if err = json.Unmarshal([]byte(text), profile); err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
@@ -0,0 +1,24 @@
+package garmin
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParseSocialProfile(t *testing.T) {
+	client := NewClient(SetEnv(ApiServiceHost, SsoPrefix))
+	page := `<script>window.VIEWER_SOCIAL_PROFILE = JSON.parse("{\"id\":1234567,\"profileId\":54321,\"displayName\":\"runner42\",\"fullName\":\"Li Lei\",\"location\":\"Shanghai\"}");</script>`
+
+	social, err := client.parseSocialProfile(page)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(54321), social.ProfileId)
+
+	profile := newProfile(*social, userSettings{MeasurementSystem: "metric", TimeZone: "Asia/Shanghai"})
+	assert.Equal(t, "runner42", profile.DisplayName)
+	assert.Equal(t, "Li Lei", profile.FullName)
+	assert.Equal(t, int64(54321), profile.UserProfilePk)
+	assert.Equal(t, "Asia/Shanghai", profile.TimeZone)
+
+	_, err = client.parseSocialProfile("<html></html>")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,148 @@
+package garmin
+
+import (
+	"bytes"
+	"errors"
+	"github.com/yqt/garmin-intl2cn/fit"
+	"io/ioutil"
+	"math"
+	"strconv"
+	"time"
+)
+
+// WeightEntry is a weight measurement of the weight-service. Body composition
+// values are nil when the source, e.g. a manual entry, did not measure them.
+type WeightEntry struct {
+	SamplePk     int64  `json:"samplePk"`
+	CalendarDate string `json:"calendarDate"`
+	// Date is the local time of the measurement in milliseconds, TimestampGMT the UTC one
+	Date         int64 `json:"date"`
+	TimestampGMT int64 `json:"timestampGMT"`
+	// Weight, BoneMass and MuscleMass in grams
+	Weight         float64  `json:"weight"`
+	BMI            *float64 `json:"bmi"`
+	BodyFat        *float64 `json:"bodyFat"`
+	BodyWater      *float64 `json:"bodyWater"`
+	BoneMass       *float64 `json:"boneMass"`
+	MuscleMass     *float64 `json:"muscleMass"`
+	PhysiqueRating *float64 `json:"physiqueRating"`
+	VisceralFat    *float64 `json:"visceralFat"`
+	MetabolicAge   *float64 `json:"metabolicAge"`
+	SourceType     string   `json:"sourceType"`
+}
+
+func (e WeightEntry) Time() time.Time {
+	return time.Unix(0, e.TimestampGMT*int64(time.Millisecond)).UTC()
+}
+
+type weightRange struct {
+	DateWeightList []WeightEntry `json:"dateWeightList"`
+}
+
+// GetWeightEntries returns the weight entries measured between the days of start and end, both inclusive.
+func (c *Client) GetWeightEntries(start time.Time, end time.Time) ([]WeightEntry, error) {
+	uri := c.ApiPrefix + "/proxy/weight-service/weight/dateRange"
+	params := map[string]interface{}{
+		"startDate": start.Format("2006-01-02"),
+		"endDate":   end.Format("2006-01-02"),
+	}
+	weights := weightRange{}
+	err := c.client.GetJson(uri, params, &weights)
+	if err != nil {
+		return nil, err
+	}
+	return weights.DateWeightList, nil
+}
+
+// AddWeight creates a manual entry, which only holds the weight. Use
+// UploadWeightEntries to keep the body composition.
+func (c *Client) AddWeight(entry WeightEntry) error {
+	uri := c.ApiPrefix + "/proxy/weight-service/user-weight"
+	const layout = "2006-01-02T15:04:05.00"
+	data := map[string]interface{}{
+		"dateTimestamp": time.Unix(0, entry.Date*int64(time.Millisecond)).UTC().Format(layout),
+		"gmtTimestamp":  entry.Time().Format(layout),
+		"unitKey":       "kg",
+		"value":         entry.Weight / 1000,
+		"sourceType":    "MANUAL",
+	}
+	_, err := c.client.Post(uri, nil, data, nil, true)
+	return err
+}
+
+// UploadWeightEntries uploads entries as a FIT weight file, the way an Index scale does.
+func (c *Client) UploadWeightEntries(entries []WeightEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	data, err := fit.EncodeBytes(weightFITFile(entries, time.Now()))
+	if err != nil {
+		return err
+	}
+	fileName := "weight_" + strconv.FormatInt(entries[0].TimestampGMT, 10) + ".fit"
+	_, err = c.UploadActivityAs(fileName, ioutil.NopCloser(bytes.NewReader(data)), FormatFIT)
+	if errors.Is(err, ErrDuplicateActivity) {
+		return nil
+	}
+	return err
+}
+
+func weightFITFile(entries []WeightEntry, created time.Time) *fit.File {
+	file := &fit.File{
+		Messages: []fit.Message{{
+			Num: fit.MesgNumFileID,
+			Fields: []fit.Field{
+				{Num: 0, Type: fit.BaseTypeEnum, Value: fit.FileTypeWeight},
+				// NOTE: manufacturer 255 is "development"
+				{Num: 1, Type: fit.BaseTypeUint16, Value: uint16(255)},
+				{Num: 2, Type: fit.BaseTypeUint16, Value: uint16(0)},
+				{Num: 3, Type: fit.BaseTypeUint32z, Value: uint32(created.Unix())},
+				{Num: 4, Type: fit.BaseTypeUint32, Value: fit.TimeToFIT(created)},
+			},
+		}},
+	}
+
+	for _, entry := range entries {
+		msg := fit.Message{
+			Num:      fit.MesgNumWeightScale,
+			LocalNum: 1,
+			Fields: []fit.Field{
+				{Num: fit.FieldNumTimestamp, Type: fit.BaseTypeUint32, Value: fit.TimeToFIT(entry.Time())},
+				{Num: 0, Type: fit.BaseTypeUint16, Value: scaledUint16(entry.Weight/1000, 100)},
+			},
+		}
+		addScaledField(&msg, 1, entry.BodyFat, 100)
+		addScaledField(&msg, 2, entry.BodyWater, 100)
+		addGramsField(&msg, 4, entry.BoneMass)
+		addGramsField(&msg, 5, entry.MuscleMass)
+		addUint8Field(&msg, 8, entry.PhysiqueRating)
+		addUint8Field(&msg, 10, entry.MetabolicAge)
+		addUint8Field(&msg, 11, entry.VisceralFat)
+		addScaledField(&msg, 13, entry.BMI, 10)
+		file.Messages = append(file.Messages, msg)
+	}
+	return file
+}
+
+func scaledUint16(v float64, scale float64) uint16 {
+	// NOTE: 0xFFFF is the invalid value of uint16
+	return uint16(math.Min(math.Round(v*scale), math.MaxUint16-1))
+}
+
+func addScaledField(msg *fit.Message, num byte, v *float64, scale float64) {
+	if v != nil {
+		msg.Fields = append(msg.Fields, fit.Field{Num: num, Type: fit.BaseTypeUint16, Value: scaledUint16(*v, scale)})
+	}
+}
+
+func addGramsField(msg *fit.Message, num byte, grams *float64) {
+	if grams != nil {
+		msg.Fields = append(msg.Fields, fit.Field{Num: num, Type: fit.BaseTypeUint16, Value: scaledUint16(*grams/1000, 100)})
+	}
+}
+
+func addUint8Field(msg *fit.Message, num byte, v *float64) {
+	if v != nil {
+		msg.Fields = append(msg.Fields, fit.Field{Num: num, Type: fit.BaseTypeUint8, Value: uint8(math.Min(math.Round(*v), math.MaxUint8-1))})
+	}
+}
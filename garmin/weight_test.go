@@ -0,0 +1,94 @@
+package garmin
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/yqt/garmin-intl2cn/fit"
+	"testing"
+	"time"
+)
+
+const weightRangeJson = `{
+	"startDate": "2021-09-01",
+	"endDate": "2021-09-08",
+	"dateWeightList": [{
+		"samplePk": 1631065600000,
+		"date": 1631094400000,
+		"calendarDate": "2021-09-08",
+		"weight": 70520.0,
+		"bmi": 22.3,
+		"bodyFat": 18.4,
+		"bodyWater": 56.2,
+		"boneMass": 3120.0,
+		"muscleMass": 32480.0,
+		"physiqueRating": 5.0,
+		"visceralFat": 6.0,
+		"metabolicAge": 28.0,
+		"sourceType": "INDEX_SCALE",
+		"timestampGMT": 1631065600000
+	}, {
+		"samplePk": 1631152000000,
+		"date": 1631180800000,
+		"calendarDate": "2021-09-09",
+		"weight": 70100.0,
+		"bmi": null,
+		"bodyFat": null,
+		"bodyWater": null,
+		"boneMass": null,
+		"muscleMass": null,
+		"physiqueRating": null,
+		"visceralFat": null,
+		"metabolicAge": null,
+		"sourceType": "MANUAL",
+		"timestampGMT": 1631152000000
+	}]
+}`
+
+func decodeWeightEntries(t *testing.T) []WeightEntry {
+	weights := weightRange{}
+	assert.Nil(t, json.Unmarshal([]byte(weightRangeJson), &weights))
+	return weights.DateWeightList
+}
+
+func TestWeightEntry_Unmarshal(t *testing.T) {
+	entries := decodeWeightEntries(t)
+	assert.Len(t, entries, 2)
+	assert.Equal(t, time.Date(2021, 9, 8, 1, 46, 40, 0, time.UTC), entries[0].Time())
+	assert.Equal(t, 18.4, *entries[0].BodyFat)
+	assert.Nil(t, entries[1].BodyFat)
+}
+
+func TestWeightFITFile(t *testing.T) {
+	entries := decodeWeightEntries(t)
+	created := time.Date(2021, 9, 10, 0, 0, 0, 0, time.UTC)
+
+	data, err := fit.EncodeBytes(weightFITFile(entries, created))
+	assert.Nil(t, err)
+	assert.Equal(t, FormatFIT, DetectFormat("", data))
+
+	decoded, err := fit.DecodeBytes(data)
+	assert.Nil(t, err)
+	fileID, ok := decoded.FileID()
+	assert.True(t, ok)
+	assert.Equal(t, fit.FileTypeWeight, fileID.Type)
+	assert.Equal(t, created, fileID.TimeCreated)
+
+	weightScales := decoded.WeightScales()
+	assert.Len(t, weightScales, 2)
+	assert.Equal(t, fit.WeightScale{
+		Timestamp:         entries[0].Time(),
+		Weight:            70.52,
+		PercentFat:        18.4,
+		PercentHydration:  56.2,
+		BoneMass:          3.12,
+		MuscleMass:        32.48,
+		PhysiqueRating:    5,
+		MetabolicAge:      28,
+		VisceralFatRating: 6,
+		BMI:               22.3,
+	}, weightScales[0])
+	assert.Equal(t, fit.WeightScale{
+		Timestamp: entries[1].Time(),
+		Weight:    70.1,
+	}, weightScales[1])
+}
@@ -0,0 +1,300 @@
+package garmin
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+const (
+	// stepsRangeDays is the longest range accepted by the daily steps stats
+	stepsRangeDays = 28
+	// metricIdRestingHeartRate is the userstats-service metric of the daily resting heart rate
+	metricIdRestingHeartRate = 60
+)
+
+// DailySummary is the daily overview shown on the garmin connect home page.
+type DailySummary struct {
+	CalendarDate             string  `json:"calendarDate"`
+	TotalSteps               int     `json:"totalSteps"`
+	DailyStepGoal            int     `json:"dailyStepGoal"`
+	TotalDistanceMeters      float64 `json:"totalDistanceMeters"`
+	TotalKilocalories        float64 `json:"totalKilocalories"`
+	ActiveKilocalories       float64 `json:"activeKilocalories"`
+	BmrKilocalories          float64 `json:"bmrKilocalories"`
+	FloorsAscended           float64 `json:"floorsAscended"`
+	FloorsDescended          float64 `json:"floorsDescended"`
+	ModerateIntensityMinutes int     `json:"moderateIntensityMinutes"`
+	VigorousIntensityMinutes int     `json:"vigorousIntensityMinutes"`
+	MinHeartRate             int     `json:"minHeartRate"`
+	MaxHeartRate             int     `json:"maxHeartRate"`
+	RestingHeartRate         int     `json:"restingHeartRate"`
+	AverageStressLevel       int     `json:"averageStressLevel"`
+	MaxStressLevel           int     `json:"maxStressLevel"`
+	BodyBatteryHighestValue  int     `json:"bodyBatteryHighestValue"`
+	BodyBatteryLowestValue   int     `json:"bodyBatteryLowestValue"`
+	BodyBatteryChargedValue  int     `json:"bodyBatteryChargedValue"`
+	BodyBatteryDrainedValue  int     `json:"bodyBatteryDrainedValue"`
+}
+
+// Sleep is the sleep of the night ending on CalendarDate. Timestamps are epoch milliseconds.
+type Sleep struct {
+	CalendarDate                string      `json:"calendarDate"`
+	SleepStartTimestampGMT      int64       `json:"sleepStartTimestampGMT"`
+	SleepEndTimestampGMT        int64       `json:"sleepEndTimestampGMT"`
+	SleepTimeSeconds            int         `json:"sleepTimeSeconds"`
+	DeepSleepSeconds            int         `json:"deepSleepSeconds"`
+	LightSleepSeconds           int         `json:"lightSleepSeconds"`
+	RemSleepSeconds             int         `json:"remSleepSeconds"`
+	AwakeSleepSeconds           int         `json:"awakeSleepSeconds"`
+	AverageRespirationValue     float64     `json:"averageRespirationValue"`
+	AverageSpO2Value            float64     `json:"averageSpO2Value"`
+	AvgSleepStress              float64     `json:"avgSleepStress"`
+	SleepScores                 SleepScores `json:"sleepScores"`
+	SleepFromDevice             bool        `json:"sleepFromDevice"`
+	DeviceRemCapable            bool        `json:"deviceRemCapable"`
+	SleepWindowConfirmed        bool        `json:"sleepWindowConfirmed"`
+	SleepWindowConfirmationType string      `json:"sleepWindowConfirmationType"`
+}
+
+type SleepScores struct {
+	Overall SleepScore `json:"overall"`
+}
+
+type SleepScore struct {
+	Value        int    `json:"value"`
+	QualifierKey string `json:"qualifierKey"`
+}
+
+type dailySleep struct {
+	DailySleepDTO Sleep `json:"dailySleepDTO"`
+}
+
+// HRVSummary is the heart rate variability measured during the night ending on CalendarDate.
+type HRVSummary struct {
+	CalendarDate      string      `json:"calendarDate"`
+	WeeklyAvg         int         `json:"weeklyAvg"`
+	LastNightAvg      int         `json:"lastNightAvg"`
+	LastNight5MinHigh int         `json:"lastNight5MinHigh"`
+	Status            string      `json:"status"`
+	FeedbackPhrase    string      `json:"feedbackPhrase"`
+	Baseline          HRVBaseline `json:"baseline"`
+}
+
+type HRVBaseline struct {
+	LowUpper      int     `json:"lowUpper"`
+	BalancedLow   int     `json:"balancedLow"`
+	BalancedUpper int     `json:"balancedUpper"`
+	MarkerValue   float64 `json:"markerValue"`
+}
+
+type dailyHRV struct {
+	HrvSummary *HRVSummary `json:"hrvSummary"`
+}
+
+// DailyStress holds the stress levels of a day. StressValuesArray contains
+// [epoch milliseconds, level] pairs, negative levels mean unmeasured.
+type DailyStress struct {
+	CalendarDate      string    `json:"calendarDate"`
+	MaxStressLevel    int       `json:"maxStressLevel"`
+	AvgStressLevel    int       `json:"avgStressLevel"`
+	StressValuesArray [][]int64 `json:"stressValuesArray"`
+}
+
+// BodyBattery is the body battery report of a day. BodyBatteryValuesArray
+// contains [epoch milliseconds, level] pairs.
+type BodyBattery struct {
+	Date                   string    `json:"date"`
+	Charged                int       `json:"charged"`
+	Drained                int       `json:"drained"`
+	StartTimestampGMT      string    `json:"startTimestampGMT"`
+	EndTimestampGMT        string    `json:"endTimestampGMT"`
+	BodyBatteryValuesArray [][]int64 `json:"bodyBatteryValuesArray"`
+}
+
+type RestingHeartRate struct {
+	CalendarDate string  `json:"calendarDate"`
+	Value        float64 `json:"value"`
+}
+
+type restingHeartRateStats struct {
+	AllMetrics struct {
+		MetricsMap struct {
+			RestingHeartRate []RestingHeartRate `json:"WELLNESS_RESTING_HEART_RATE"`
+		} `json:"metricsMap"`
+	} `json:"allMetrics"`
+}
+
+type DailySteps struct {
+	CalendarDate  string  `json:"calendarDate"`
+	TotalSteps    int     `json:"totalSteps"`
+	TotalDistance float64 `json:"totalDistance"`
+	StepGoal      int     `json:"stepGoal"`
+}
+
+// GetDailySummaries returns the daily summaries between the days of start and end, both inclusive.
+func (c *Client) GetDailySummaries(start time.Time, end time.Time) ([]DailySummary, error) {
+	profile, err := c.Profile()
+	if err != nil {
+		return nil, err
+	}
+	uri := c.ApiPrefix + "/proxy/usersummary-service/usersummary/daily/" + profile.DisplayName
+	summaries := make([]DailySummary, 0)
+	err = eachDay(start, end, func(date string) error {
+		summary := DailySummary{}
+		ok, err := c.getOptionalJson(uri, map[string]interface{}{"calendarDate": date}, &summary)
+		if ok {
+			summaries = append(summaries, summary)
+		}
+		return err
+	})
+	return summaries, err
+}
+
+// GetSleep returns the sleep of the nights ending between the days of start and end,
+// both inclusive. Nights without sleep data are left out.
+func (c *Client) GetSleep(start time.Time, end time.Time) ([]Sleep, error) {
+	profile, err := c.Profile()
+	if err != nil {
+		return nil, err
+	}
+	uri := c.ApiPrefix + "/proxy/wellness-service/wellness/dailySleepData/" + profile.DisplayName
+	sleeps := make([]Sleep, 0)
+	err = eachDay(start, end, func(date string) error {
+		sleep := dailySleep{}
+		params := map[string]interface{}{
+			"date":                  date,
+			"nonSleepBufferMinutes": 60,
+		}
+		ok, err := c.getOptionalJson(uri, params, &sleep)
+		if ok && sleep.DailySleepDTO.SleepTimeSeconds > 0 {
+			sleeps = append(sleeps, sleep.DailySleepDTO)
+		}
+		return err
+	})
+	return sleeps, err
+}
+
+// GetHRV returns the HRV summaries between the days of start and end, both inclusive.
+// Days without HRV status, e.g. on devices not measuring it, are left out.
+func (c *Client) GetHRV(start time.Time, end time.Time) ([]HRVSummary, error) {
+	summaries := make([]HRVSummary, 0)
+	err := eachDay(start, end, func(date string) error {
+		hrv := dailyHRV{}
+		ok, err := c.getOptionalJson(c.ApiPrefix+"/proxy/hrv-service/hrv/"+date, nil, &hrv)
+		if ok && hrv.HrvSummary != nil {
+			summaries = append(summaries, *hrv.HrvSummary)
+		}
+		return err
+	})
+	return summaries, err
+}
+
+// GetStress returns the stress levels between the days of start and end, both inclusive.
+func (c *Client) GetStress(start time.Time, end time.Time) ([]DailyStress, error) {
+	stresses := make([]DailyStress, 0)
+	err := eachDay(start, end, func(date string) error {
+		stress := DailyStress{}
+		ok, err := c.getOptionalJson(c.ApiPrefix+"/proxy/wellness-service/wellness/dailyStress/"+date, nil, &stress)
+		if ok {
+			stresses = append(stresses, stress)
+		}
+		return err
+	})
+	return stresses, err
+}
+
+// GetBodyBattery returns the body battery reports between the days of start and end, both inclusive.
+func (c *Client) GetBodyBattery(start time.Time, end time.Time) ([]BodyBattery, error) {
+	uri := c.ApiPrefix + "/proxy/wellness-service/wellness/bodyBattery/reports/daily"
+	params := map[string]interface{}{
+		"startDate": start.Format("2006-01-02"),
+		"endDate":   end.Format("2006-01-02"),
+	}
+	reports := make([]BodyBattery, 0)
+	err := c.client.GetJson(uri, params, &reports)
+	return reports, err
+}
+
+// GetRestingHeartRate returns the resting heart rates between the days of start and end, both inclusive.
+func (c *Client) GetRestingHeartRate(start time.Time, end time.Time) ([]RestingHeartRate, error) {
+	profile, err := c.Profile()
+	if err != nil {
+		return nil, err
+	}
+	uri := c.ApiPrefix + "/proxy/userstats-service/wellness/daily/" + profile.DisplayName
+	params := map[string]interface{}{
+		"fromDate":  start.Format("2006-01-02"),
+		"untilDate": end.Format("2006-01-02"),
+		"metricId":  metricIdRestingHeartRate,
+	}
+	stats := restingHeartRateStats{}
+	if err = c.client.GetJson(uri, params, &stats); err != nil {
+		return nil, err
+	}
+	rates := stats.AllMetrics.MetricsMap.RestingHeartRate
+	if rates == nil {
+		rates = make([]RestingHeartRate, 0)
+	}
+	return rates, nil
+}
+
+// GetSteps returns the daily steps between the days of start and end, both inclusive.
+func (c *Client) GetSteps(start time.Time, end time.Time) ([]DailySteps, error) {
+	steps := make([]DailySteps, 0)
+	for _, r := range splitDateRange(start, end, stepsRangeDays) {
+		uri := c.ApiPrefix + "/proxy/usersummary-service/stats/steps/daily/" +
+			r[0].Format("2006-01-02") + "/" + r[1].Format("2006-01-02")
+		page := make([]DailySteps, 0)
+		if err := c.client.GetJson(uri, nil, &page); err != nil {
+			return nil, err
+		}
+		steps = append(steps, page...)
+	}
+	return steps, nil
+}
+
+// getOptionalJson decodes the response into v and reports false for the empty
+// body garmin sends for days without data.
+func (c *Client) getOptionalJson(uri string, params map[string]interface{}, v interface{}) (bool, error) {
+	respText, err := c.client.Get(uri, params)
+	if err != nil {
+		return false, err
+	}
+	if strings.TrimSpace(respText) == "" {
+		return false, nil
+	}
+	if err = json.Unmarshal([]byte(respText), v); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// eachDay calls fn with every date between the days of start and end, both inclusive.
+func eachDay(start time.Time, end time.Time, fn func(date string) error) error {
+	for day := truncateDay(start); !day.After(truncateDay(end)); day = day.AddDate(0, 0, 1) {
+		if err := fn(day.Format("2006-01-02")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// splitDateRange splits the days between start and end, both inclusive, into
+// ranges of at most days days.
+func splitDateRange(start time.Time, end time.Time, days int) [][2]time.Time {
+	ranges := make([][2]time.Time, 0)
+	end = truncateDay(end)
+	for from := truncateDay(start); !from.After(end); from = from.AddDate(0, 0, days) {
+		to := from.AddDate(0, 0, days-1)
+		if to.After(end) {
+			to = end
+		}
+		ranges = append(ranges, [2]time.Time{from, to})
+	}
+	return ranges
+}
+
+func truncateDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,73 @@
+package garmin
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestSplitDateRange(t *testing.T) {
+	start := time.Date(2021, 1, 1, 8, 30, 0, 0, time.UTC)
+	end := time.Date(2021, 2, 3, 0, 0, 0, 0, time.UTC)
+
+	ranges := splitDateRange(start, end, 28)
+	assert.Len(t, ranges, 2)
+	assert.Equal(t, "2021-01-01", ranges[0][0].Format("2006-01-02"))
+	assert.Equal(t, "2021-01-28", ranges[0][1].Format("2006-01-02"))
+	assert.Equal(t, "2021-01-29", ranges[1][0].Format("2006-01-02"))
+	assert.Equal(t, "2021-02-03", ranges[1][1].Format("2006-01-02"))
+
+	assert.Len(t, splitDateRange(end, end, 28), 1)
+	assert.Len(t, splitDateRange(end, start, 28), 0)
+}
+
+func TestEachDay(t *testing.T) {
+	dates := make([]string, 0)
+	err := eachDay(time.Date(2020, 2, 28, 23, 0, 0, 0, time.UTC), time.Date(2020, 3, 1, 1, 0, 0, 0, time.UTC), func(date string) error {
+		dates = append(dates, date)
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"2020-02-28", "2020-02-29", "2020-03-01"}, dates)
+}
+
+func TestWellness_Decode(t *testing.T) {
+	sleep := dailySleep{}
+	assert.Nil(t, json.Unmarshal([]byte(`{
+		"dailySleepDTO": {
+			"calendarDate": "2021-01-02",
+			"sleepTimeSeconds": 27000,
+			"deepSleepSeconds": 5400,
+			"remSleepSeconds": 6300,
+			"sleepStartTimestampGMT": 1609535400000,
+			"sleepScores": {"overall": {"value": 82, "qualifierKey": "GOOD"}}
+		},
+		"sleepMovement": []
+	}`), &sleep))
+	assert.Equal(t, 27000, sleep.DailySleepDTO.SleepTimeSeconds)
+	assert.Equal(t, 82, sleep.DailySleepDTO.SleepScores.Overall.Value)
+
+	hrv := dailyHRV{}
+	assert.Nil(t, json.Unmarshal([]byte(`{"hrvSummary": null}`), &hrv))
+	assert.Nil(t, hrv.HrvSummary)
+
+	stats := restingHeartRateStats{}
+	assert.Nil(t, json.Unmarshal([]byte(`{
+		"userProfilePK": 54321,
+		"allMetrics": {"metricsMap": {"WELLNESS_RESTING_HEART_RATE": [
+			{"value": 52.0, "calendarDate": "2021-01-01"},
+			{"value": 50.0, "calendarDate": "2021-01-02"}
+		]}}
+	}`), &stats))
+	assert.Len(t, stats.AllMetrics.MetricsMap.RestingHeartRate, 2)
+	assert.Equal(t, 50.0, stats.AllMetrics.MetricsMap.RestingHeartRate[1].Value)
+
+	stress := DailyStress{}
+	assert.Nil(t, json.Unmarshal([]byte(`{
+		"calendarDate": "2021-01-02",
+		"avgStressLevel": 31,
+		"stressValuesArray": [[1609545600000, 25], [1609545780000, -1]]
+	}`), &stress))
+	assert.Equal(t, int64(-1), stress.StressValuesArray[1][1])
+}
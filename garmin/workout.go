@@ -0,0 +1,153 @@
+package garmin
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+// Workout is a structured workout of the workout-service. Only the summary is
+// decoded, Raw holds the whole workout including its segments and steps.
+type Workout struct {
+	WorkoutId               int64     `json:"workoutId"`
+	OwnerId                 int64     `json:"ownerId"`
+	WorkoutName             string    `json:"workoutName"`
+	Description             string    `json:"description"`
+	SportType               SportType `json:"sportType"`
+	EstimatedDurationInSecs int       `json:"estimatedDurationInSecs"`
+	CreatedDate             string    `json:"createdDate"`
+	UpdatedDate             string    `json:"updatedDate"`
+
+	Raw json.RawMessage `json:"-"`
+}
+
+type SportType struct {
+	SportTypeId  int    `json:"sportTypeId"`
+	SportTypeKey string `json:"sportTypeKey"`
+}
+
+func (w *Workout) UnmarshalJSON(data []byte) error {
+	type workout Workout
+	item := workout{}
+	if err := json.Unmarshal(data, &item); err != nil {
+		return err
+	}
+	*w = Workout(item)
+	w.Raw = append(json.RawMessage(nil), data...)
+	return nil
+}
+
+// ScheduledWorkout is a workout planned on the calendar.
+type ScheduledWorkout struct {
+	Id        int64  `json:"id"`
+	ItemType  string `json:"itemType"`
+	WorkoutId int64  `json:"workoutId"`
+	Title     string `json:"title"`
+	Date      string `json:"date"`
+}
+
+// workoutServerFields are assigned by garmin and must not be sent when creating a workout.
+var workoutServerFields = []string{"workoutId", "ownerId", "author", "createdDate", "updatedDate", "shared", "consumer", "atpPlanId", "trainingPlanId"}
+
+func (c *Client) GetWorkouts(start int64, limit int64) ([]Workout, error) {
+	uri := c.ApiPrefix + "/proxy/workout-service/workouts"
+	params := map[string]interface{}{
+		"start":          start,
+		"limit":          limit,
+		"myWorkoutsOnly": true,
+	}
+	workouts := make([]Workout, 0)
+	err := c.client.GetJson(uri, params, &workouts)
+	return workouts, err
+}
+
+// GetWorkout returns a workout with its steps, which GetWorkouts leaves out.
+func (c *Client) GetWorkout(id int64) (Workout, error) {
+	uri := c.ApiPrefix + "/proxy/workout-service/workout/" + strconv.FormatInt(id, 10)
+	workout := Workout{}
+	err := c.client.GetJson(uri, nil, &workout)
+	return workout, err
+}
+
+// CreateWorkout creates a copy of workout, as returned by GetWorkout, owned by the
+// logged in account.
+func (c *Client) CreateWorkout(workout Workout) (Workout, error) {
+	data, err := workoutCreatePayload(workout)
+	if err != nil {
+		return Workout{}, err
+	}
+
+	uri := c.ApiPrefix + "/proxy/workout-service/workout"
+	created := Workout{}
+	err = c.client.PostJson(uri, nil, data, nil, true, &created)
+	return created, err
+}
+
+func workoutCreatePayload(workout Workout) (map[string]interface{}, error) {
+	data := make(map[string]interface{})
+	if err := json.Unmarshal(workout.Raw, &data); err != nil {
+		return nil, err
+	}
+	for _, field := range workoutServerFields {
+		delete(data, field)
+	}
+	removeStepIds(data)
+	return data, nil
+}
+
+type workoutSchedule struct {
+	WorkoutScheduleId int64 `json:"workoutScheduleId"`
+}
+
+// ScheduleWorkout plans a workout on the calendar and returns the id of the calendar item.
+func (c *Client) ScheduleWorkout(workoutId int64, date time.Time) (int64, error) {
+	uri := c.ApiPrefix + "/proxy/workout-service/schedule/" + strconv.FormatInt(workoutId, 10)
+	data := map[string]interface{}{
+		"date": date.Format("2006-01-02"),
+	}
+	schedule := workoutSchedule{}
+	err := c.client.PostJson(uri, nil, data, nil, true, &schedule)
+	return schedule.WorkoutScheduleId, err
+}
+
+type calendarMonth struct {
+	CalendarItems []ScheduledWorkout `json:"calendarItems"`
+}
+
+// GetScheduledWorkouts returns the workouts planned between the days of start and end, both inclusive.
+func (c *Client) GetScheduledWorkouts(start time.Time, end time.Time) ([]ScheduledWorkout, error) {
+	startDate, endDate := start.Format("2006-01-02"), end.Format("2006-01-02")
+	scheduled := make([]ScheduledWorkout, 0)
+	month := time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, time.UTC)
+	for !month.After(end) {
+		// NOTE: months of the calendar-service are 0 based
+		uri := c.ApiPrefix + "/proxy/calendar-service/year/" + strconv.Itoa(month.Year()) +
+			"/month/" + strconv.Itoa(int(month.Month())-1)
+		calendar := calendarMonth{}
+		if err := c.client.GetJson(uri, nil, &calendar); err != nil {
+			return nil, err
+		}
+		for _, item := range calendar.CalendarItems {
+			if item.ItemType == "workout" && item.Date >= startDate && item.Date <= endDate {
+				scheduled = append(scheduled, item)
+			}
+		}
+		month = month.AddDate(0, 1, 0)
+	}
+	return scheduled, nil
+}
+
+// removeStepIds drops the step ids of the source account from the nested steps of a workout.
+func removeStepIds(v interface{}) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		delete(v, "stepId")
+		for _, child := range v {
+			removeStepIds(child)
+		}
+	case []interface{}:
+		for _, child := range v {
+			removeStepIds(child)
+		}
+	}
+}
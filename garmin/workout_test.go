@@ -0,0 +1,67 @@
+package garmin
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+const workoutJson = `{
+	"workoutId": 123456,
+	"ownerId": 12345,
+	"workoutName": "5x1km",
+	"description": "intervals",
+	"updatedDate": "2021-09-01T08:00:00.0",
+	"createdDate": "2021-09-01T08:00:00.0",
+	"sportType": {"sportTypeId": 1, "sportTypeKey": "running"},
+	"author": {"userProfilePk": 12345, "displayName": "coach"},
+	"estimatedDurationInSecs": 2700,
+	"workoutSegments": [{
+		"segmentOrder": 1,
+		"sportType": {"sportTypeId": 1, "sportTypeKey": "running"},
+		"workoutSteps": [
+			{"type": "ExecutableStepDTO", "stepId": 11, "stepOrder": 1, "stepType": {"stepTypeId": 1, "stepTypeKey": "warmup"}},
+			{"type": "RepeatGroupDTO", "stepId": 12, "stepOrder": 2, "numberOfIterations": 5, "childStepId": 1,
+				"workoutSteps": [
+					{"type": "ExecutableStepDTO", "stepId": 13, "stepOrder": 3, "childStepId": 1,
+						"endCondition": {"conditionTypeKey": "distance"}, "endConditionValue": 1000.0}
+				]}
+		]
+	}]
+}`
+
+func TestWorkout_Unmarshal(t *testing.T) {
+	workout := Workout{}
+	assert.Nil(t, json.Unmarshal([]byte(workoutJson), &workout))
+	assert.Equal(t, int64(123456), workout.WorkoutId)
+	assert.Equal(t, "5x1km", workout.WorkoutName)
+	assert.Equal(t, "running", workout.SportType.SportTypeKey)
+	assert.Equal(t, 2700, workout.EstimatedDurationInSecs)
+	assert.Equal(t, workoutJson, string(workout.Raw))
+}
+
+func TestWorkoutCreatePayload(t *testing.T) {
+	workout := Workout{}
+	assert.Nil(t, json.Unmarshal([]byte(workoutJson), &workout))
+
+	data, err := workoutCreatePayload(workout)
+	assert.Nil(t, err)
+	for _, field := range []string{"workoutId", "ownerId", "author", "createdDate", "updatedDate"} {
+		_, ok := data[field]
+		assert.False(t, ok, field)
+	}
+	assert.Equal(t, "5x1km", data["workoutName"])
+
+	steps := data["workoutSegments"].([]interface{})[0].(map[string]interface{})["workoutSteps"].([]interface{})
+	assert.Len(t, steps, 2)
+	repeat := steps[1].(map[string]interface{})
+	_, ok := repeat["stepId"]
+	assert.False(t, ok)
+	assert.Equal(t, 5.0, repeat["numberOfIterations"])
+	child := repeat["workoutSteps"].([]interface{})[0].(map[string]interface{})
+	_, ok = child["stepId"]
+	assert.False(t, ok)
+	// childStepId links the steps of a repeat group and is kept
+	assert.Equal(t, 1.0, child["childStepId"])
+	assert.Equal(t, 1000.0, child["endConditionValue"])
+}
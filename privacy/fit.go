@@ -0,0 +1,146 @@
+package privacy
+
+import (
+	"github.com/yqt/garmin-intl2cn/fit"
+	"math"
+)
+
+type trackPoint struct {
+	timestamp uint32
+	lat       float64
+	lng       float64
+}
+
+// FIT removes record positions inside zones. Lap and session start/end positions
+// inside a zone are moved to the nearest retained point, and the session bounding
+// box is recomputed, so the activity start shown by Garmin no longer reveals the zone.
+func FIT(data []byte, zones []Zone, mode Mode) ([]byte, error) {
+	file, err := fit.DecodeBytes(data)
+	if err != nil {
+		return nil, err
+	}
+
+	hidden := 0
+	retained := make([]trackPoint, 0)
+	messages := make([]fit.Message, 0, len(file.Messages))
+	for _, msg := range file.Messages {
+		if msg.Num == fit.MesgNumRecord {
+			if lat, lng, ok := position(&msg, 0, 1); ok {
+				if inZones(zones, lat, lng) {
+					hidden++
+					if mode == ModeTruncate {
+						continue
+					}
+					msg.RemoveField(0)
+					msg.RemoveField(1)
+				} else {
+					timestamp, _ := uintField(&msg, fit.FieldNumTimestamp)
+					retained = append(retained, trackPoint{timestamp: timestamp, lat: lat, lng: lng})
+				}
+			}
+		}
+		messages = append(messages, msg)
+	}
+
+	for i := range messages {
+		msg := &messages[i]
+		switch msg.Num {
+		case fit.MesgNumLap:
+			start, _ := uintField(msg, 2)
+			end, ok := uintField(msg, fit.FieldNumTimestamp)
+			if !ok {
+				end = math.MaxUint32
+			}
+			hidePosition(msg, 3, 4, zones, firstPoint(retained, start, end))
+			hidePosition(msg, 5, 6, zones, lastPoint(retained, start, end))
+		case fit.MesgNumSession:
+			hidePosition(msg, 3, 4, zones, firstPoint(retained, 0, math.MaxUint32))
+			if hidden > 0 {
+				setBounds(msg, retained)
+			}
+		}
+	}
+
+	file.Messages = messages
+	return fit.EncodeBytes(file)
+}
+
+func position(msg *fit.Message, latNum byte, lngNum byte) (float64, float64, bool) {
+	latField, lngField := msg.Field(latNum), msg.Field(lngNum)
+	if latField == nil || lngField == nil {
+		return 0, 0, false
+	}
+	lat, latOk := latField.Int()
+	lng, lngOk := lngField.Int()
+	if !latOk || !lngOk {
+		return 0, 0, false
+	}
+	return fit.SemicirclesToDegrees(int32(lat)), fit.SemicirclesToDegrees(int32(lng)), true
+}
+
+func uintField(msg *fit.Message, num byte) (uint32, bool) {
+	if f := msg.Field(num); f != nil {
+		if v, ok := f.Uint(); ok {
+			return uint32(v), true
+		}
+	}
+	return 0, false
+}
+
+func setPosition(msg *fit.Message, latNum byte, lngNum byte, lat float64, lng float64) {
+	msg.SetField(fit.Field{Num: latNum, Type: fit.BaseTypeSint32, Value: fit.DegreesToSemicircles(lat)})
+	msg.SetField(fit.Field{Num: lngNum, Type: fit.BaseTypeSint32, Value: fit.DegreesToSemicircles(lng)})
+}
+
+// hidePosition replaces a position inside a zone with replacement, or removes it if there is none.
+func hidePosition(msg *fit.Message, latNum byte, lngNum byte, zones []Zone, replacement *trackPoint) {
+	lat, lng, ok := position(msg, latNum, lngNum)
+	if !ok || !inZones(zones, lat, lng) {
+		return
+	}
+	if replacement == nil {
+		msg.RemoveField(latNum)
+		msg.RemoveField(lngNum)
+		return
+	}
+	setPosition(msg, latNum, lngNum, replacement.lat, replacement.lng)
+}
+
+func firstPoint(points []trackPoint, start uint32, end uint32) *trackPoint {
+	for i := range points {
+		if points[i].timestamp >= start && points[i].timestamp <= end {
+			return &points[i]
+		}
+	}
+	return nil
+}
+
+func lastPoint(points []trackPoint, start uint32, end uint32) *trackPoint {
+	for i := len(points) - 1; i >= 0; i-- {
+		if points[i].timestamp >= start && points[i].timestamp <= end {
+			return &points[i]
+		}
+	}
+	return nil
+}
+
+// setBounds recomputes the north-east and south-west corners of a session.
+func setBounds(msg *fit.Message, points []trackPoint) {
+	if len(points) == 0 {
+		for _, num := range []byte{29, 30, 31, 32} {
+			msg.RemoveField(num)
+		}
+		return
+	}
+	north, east, south, west := points[0].lat, points[0].lng, points[0].lat, points[0].lng
+	for _, p := range points[1:] {
+		north, south = math.Max(north, p.lat), math.Min(south, p.lat)
+		east, west = math.Max(east, p.lng), math.Min(west, p.lng)
+	}
+	if msg.Field(29) != nil {
+		setPosition(msg, 29, 30, north, east)
+	}
+	if msg.Field(31) != nil {
+		setPosition(msg, 31, 32, south, west)
+	}
+}
@@ -0,0 +1,48 @@
+// Package privacy removes GPS points recorded inside privacy zones from activity files.
+package privacy
+
+import "math"
+
+const earthRadius = 6371008.8
+
+// Zone is a circle around a WGS-84 position.
+type Zone struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	// Radius in meters
+	Radius float64 `json:"radius"`
+}
+
+type Mode string
+
+const (
+	// ModeStrip keeps the points inside a zone but drops their position, so
+	// time, heart rate and distance are preserved. GPX points can't exist
+	// without a position and are always removed.
+	ModeStrip Mode = "strip"
+	// ModeTruncate removes the points inside a zone altogether.
+	ModeTruncate Mode = "truncate"
+)
+
+func (z Zone) Contains(lat float64, lng float64) bool {
+	return Distance(z.Latitude, z.Longitude, lat, lng) <= z.Radius
+}
+
+// Distance returns the great-circle distance in meters between two positions.
+func Distance(lat1 float64, lng1 float64, lat2 float64, lng2 float64) float64 {
+	radLat1, radLat2 := lat1*math.Pi/180, lat2*math.Pi/180
+	dLat := radLat2 - radLat1
+	dLng := (lng2 - lng1) * math.Pi / 180
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(radLat1)*math.Cos(radLat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadius * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+func inZones(zones []Zone, lat float64, lng float64) bool {
+	for _, zone := range zones {
+		if zone.Contains(lat, lng) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,125 @@
+package privacy
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/yqt/garmin-intl2cn/fit"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+// home covers the first two records of fit/testdata/activity.fit, about 15m apart
+var home = []Zone{{Latitude: 31.2304, Longitude: 121.4737, Radius: 20}}
+
+func TestZone_Contains(t *testing.T) {
+	assert.True(t, home[0].Contains(31.2304, 121.4737))
+	assert.True(t, home[0].Contains(31.2305, 121.4738))
+	assert.False(t, home[0].Contains(31.2306, 121.4739))
+	assert.InDelta(t, 111195, Distance(0, 0, 1, 0), 1)
+}
+
+func decodeFIT(t *testing.T, zones []Zone, mode Mode) *fit.File {
+	data, err := ioutil.ReadFile("../fit/testdata/activity.fit")
+	assert.Nil(t, err)
+	hidden, err := FIT(data, zones, mode)
+	assert.Nil(t, err)
+	file, err := fit.DecodeBytes(hidden)
+	assert.Nil(t, err)
+	return file
+}
+
+func TestFIT_Strip(t *testing.T) {
+	file := decodeFIT(t, home, ModeStrip)
+
+	records := file.Records()
+	assert.Len(t, records, 5)
+	assert.False(t, records[0].HasPosition)
+	assert.False(t, records[1].HasPosition)
+	assert.True(t, records[2].HasPosition)
+	assert.InDelta(t, 6, records[2].Distance, 1e-9)
+
+	session := file.Sessions()[0]
+	assert.True(t, session.HasStartPosition)
+	assert.Equal(t, records[2].Position, session.StartPosition)
+	lap := file.Laps()[0]
+	assert.Equal(t, records[2].Position, lap.StartPosition)
+	assert.Equal(t, records[4].Position, lap.EndPosition)
+}
+
+func TestFIT_Truncate(t *testing.T) {
+	file := decodeFIT(t, home, ModeTruncate)
+
+	records := file.Records()
+	assert.Len(t, records, 3)
+	for _, record := range records {
+		assert.False(t, home[0].Contains(record.Position.Lat, record.Position.Long))
+	}
+}
+
+func TestFIT_EverythingHidden(t *testing.T) {
+	file := decodeFIT(t, []Zone{{Latitude: 31.2304, Longitude: 121.4737, Radius: 1000}}, ModeStrip)
+
+	for _, record := range file.Records() {
+		assert.False(t, record.HasPosition)
+	}
+	assert.False(t, file.Sessions()[0].HasStartPosition)
+	assert.False(t, file.Laps()[0].HasEndPosition)
+}
+
+const gpx = `<?xml version="1.0" encoding="UTF-8"?>
+<gpx version="1.1" creator="Garmin Connect">
+  <trk>
+    <trkseg>
+      <trkpt lat="31.2304" lon="121.4737">
+        <ele>12.0</ele>
+      </trkpt>
+      <trkpt lat="31.2310" lon="121.4745">
+        <ele>12.0</ele>
+      </trkpt>
+      <trkpt lat="31.2305" lon="121.4738"/>
+    </trkseg>
+  </trk>
+</gpx>`
+
+func TestGPX(t *testing.T) {
+	hidden, err := GPX([]byte(gpx), home, ModeStrip)
+	assert.Nil(t, err)
+
+	assert.Equal(t, 1, strings.Count(string(hidden), "<trkpt"))
+	assert.Contains(t, string(hidden), `lat="31.2310"`)
+}
+
+const tcx = `<?xml version="1.0" encoding="UTF-8"?>
+<TrainingCenterDatabase xmlns="http://www.garmin.com/xmlschemas/TrainingCenterDatabase/v2">
+  <Activities><Activity Sport="Running"><Lap StartTime="2021-09-08T01:46:40.000Z"><Track>
+    <Trackpoint>
+      <Time>2021-09-08T01:46:40.000Z</Time>
+      <Position>
+        <LatitudeDegrees>31.2304</LatitudeDegrees>
+        <LongitudeDegrees>121.4737</LongitudeDegrees>
+      </Position>
+      <HeartRateBpm><Value>120</Value></HeartRateBpm>
+    </Trackpoint>
+    <Trackpoint>
+      <Time>2021-09-08T01:46:41.000Z</Time>
+      <Position>
+        <LatitudeDegrees>31.2310</LatitudeDegrees>
+        <LongitudeDegrees>121.4745</LongitudeDegrees>
+      </Position>
+    </Trackpoint>
+  </Track></Lap></Activity></Activities>
+</TrainingCenterDatabase>`
+
+func TestTCX(t *testing.T) {
+	stripped, err := TCX([]byte(tcx), home, ModeStrip)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, strings.Count(string(stripped), "<Trackpoint>"))
+	assert.Equal(t, 1, strings.Count(string(stripped), "<Position>"))
+	assert.Contains(t, string(stripped), "<Value>120</Value>")
+	assert.NotContains(t, string(stripped), "<LatitudeDegrees>31.2304</LatitudeDegrees>")
+
+	truncated, err := TCX([]byte(tcx), home, ModeTruncate)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, strings.Count(string(truncated), "<Trackpoint>"))
+	assert.NotContains(t, string(truncated), "<Value>120</Value>")
+}
@@ -0,0 +1,53 @@
+package privacy
+
+import (
+	"regexp"
+	"strconv"
+)
+
+var (
+	gpxPointPattern    = regexp.MustCompile(`(?s)<((?:\w+:)?(?:trkpt|rtept|wpt))\b([^>]*?)(?:/>|>.*?</(?:\w+:)?(?:trkpt|rtept|wpt)>)`)
+	gpxLatPattern      = regexp.MustCompile(`\blat\s*=\s*["']([-+0-9.eE]+)["']`)
+	gpxLonPattern      = regexp.MustCompile(`\blon\s*=\s*["']([-+0-9.eE]+)["']`)
+	tcxPointPattern    = regexp.MustCompile(`(?s)[ \t]*<((?:\w+:)?Trackpoint)>.*?</(?:\w+:)?Trackpoint>\r?\n?`)
+	tcxPositionPattern = regexp.MustCompile(`(?s)[ \t]*<(?:\w+:)?Position>.*?</(?:\w+:)?Position>\r?\n?`)
+	tcxLatPattern      = regexp.MustCompile(`<(?:\w+:)?LatitudeDegrees>\s*([-+0-9.eE]+)\s*</`)
+	tcxLonPattern      = regexp.MustCompile(`<(?:\w+:)?LongitudeDegrees>\s*([-+0-9.eE]+)\s*</`)
+)
+
+// GPX removes track, route and way points inside zones. The mode is ignored
+// since a GPX point can't exist without a position.
+func GPX(data []byte, zones []Zone, mode Mode) ([]byte, error) {
+	return gpxPointPattern.ReplaceAllFunc(data, func(point []byte) []byte {
+		lat, latOk := parseFloat(gpxLatPattern, point)
+		lng, lngOk := parseFloat(gpxLonPattern, point)
+		if latOk && lngOk && inZones(zones, lat, lng) {
+			return nil
+		}
+		return point
+	}), nil
+}
+
+// TCX removes the position (ModeStrip) or the whole trackpoint (ModeTruncate) of trackpoints inside zones.
+func TCX(data []byte, zones []Zone, mode Mode) ([]byte, error) {
+	return tcxPointPattern.ReplaceAllFunc(data, func(point []byte) []byte {
+		lat, latOk := parseFloat(tcxLatPattern, point)
+		lng, lngOk := parseFloat(tcxLonPattern, point)
+		if !latOk || !lngOk || !inZones(zones, lat, lng) {
+			return point
+		}
+		if mode == ModeTruncate {
+			return nil
+		}
+		return tcxPositionPattern.ReplaceAll(point, nil)
+	}), nil
+}
+
+func parseFloat(pattern *regexp.Regexp, data []byte) (float64, bool) {
+	match := pattern.FindSubmatch(data)
+	if match == nil {
+		return 0, false
+	}
+	v, err := strconv.ParseFloat(string(match[1]), 64)
+	return v, err == nil
+}
@@ -0,0 +1,179 @@
+// Package s3 is a minimal client of S3-compatible object storage such as MinIO,
+// signing requests with AWS Signature Version 4.
+package s3
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// MetaSHA256 is the user metadata holding the hex SHA-256 of an object, set by Put.
+const MetaSHA256 = "X-Amz-Meta-Sha256"
+
+type Config struct {
+	// Endpoint is the scheme and host of the service, e.g. http://localhost:9000
+	Endpoint        string
+	Region          string
+	Bucket          string
+	AccessKeyId     string
+	SecretAccessKey string
+	// Prefix is prepended to every key, e.g. "garmin/"
+	Prefix string
+	// PathStyle addresses the bucket as endpoint/bucket, as MinIO expects,
+	// instead of bucket.endpoint
+	PathStyle bool
+}
+
+type Client struct {
+	config             Config
+	httpClient         *http.Client
+	now                func() time.Time
+	forbiddenAsMissing bool
+}
+
+type Option func(c *Client)
+
+func HTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// ForbiddenAsMissing reads 403 Forbidden answers to HEAD as missing keys, which
+// is what AWS answers for missing keys when the credentials lack s3:ListBucket.
+func ForbiddenAsMissing() Option {
+	return func(c *Client) {
+		c.forbiddenAsMissing = true
+	}
+}
+
+func NewClient(config Config, opts ...Option) *Client {
+	if config.Region == "" {
+		config.Region = "us-east-1"
+	}
+	c := &Client{
+		config:     config,
+		httpClient: http.DefaultClient,
+		now:        time.Now,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+func (c *Client) Exists(ctx context.Context, key string) (bool, error) {
+	resp, err := c.do(ctx, http.MethodHead, key, nil, nil)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	case http.StatusForbidden:
+		if c.forbiddenAsMissing {
+			return false, nil
+		}
+	}
+	return false, statusError(http.MethodHead, key, resp)
+}
+
+func (c *Client) Get(ctx context.Context, key string) ([]byte, error) {
+	resp, err := c.do(ctx, http.MethodGet, key, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, statusError(http.MethodGet, key, resp)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+// Put uploads data with its SHA-256 in MetaSHA256 and its MD5 for the service
+// to check. An object already holding the same content is left untouched.
+func (c *Client) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	hash := hexSHA256(data)
+
+	resp, err := c.do(ctx, http.MethodHead, key, nil, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusOK && resp.Header.Get(MetaSHA256) == hash {
+		return nil
+	}
+	return c.put(ctx, key, data, hash, contentType)
+}
+
+// PutUnchecked uploads data like Put without looking up the stored object first,
+// for callers which already know key is missing.
+func (c *Client) PutUnchecked(ctx context.Context, key string, data []byte, contentType string) error {
+	return c.put(ctx, key, data, hexSHA256(data), contentType)
+}
+
+func (c *Client) put(ctx context.Context, key string, data []byte, hash string, contentType string) error {
+	md5Sum := md5.Sum(data)
+	headers := map[string]string{
+		"Content-MD5": base64.StdEncoding.EncodeToString(md5Sum[:]),
+		MetaSHA256:    hash,
+	}
+	if contentType != "" {
+		headers["Content-Type"] = contentType
+	}
+	resp, err := c.do(ctx, http.MethodPut, key, data, headers)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return statusError(http.MethodPut, key, resp)
+	}
+	return nil
+}
+
+func (c *Client) do(ctx context.Context, method string, key string, data []byte, headers map[string]string) (*http.Response, error) {
+	req, err := http.NewRequest(method, c.objectURL(key), bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	sign(req, hexSHA256(data), c.config, c.now())
+	return c.httpClient.Do(req)
+}
+
+func (c *Client) objectURL(key string) string {
+	endpoint := strings.TrimSuffix(c.config.Endpoint, "/")
+	path := "/" + escapePath(c.config.Prefix+strings.TrimPrefix(key, "/"))
+	if c.config.PathStyle {
+		return endpoint + "/" + c.config.Bucket + path
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return endpoint + "/" + c.config.Bucket + path
+	}
+	u.Host = c.config.Bucket + "." + u.Host
+	return u.String() + path
+}
+
+func statusError(method string, key string, resp *http.Response) error {
+	body, _ := ioutil.ReadAll(resp.Body)
+	return fmt.Errorf("s3: %s %s: %s %s", method, key, resp.Status, strings.TrimSpace(string(body)))
+}
@@ -0,0 +1,113 @@
+package sync
+
+import (
+	"context"
+	"github.com/yqt/garmin-intl2cn/archive"
+	"github.com/yqt/garmin-intl2cn/garmin"
+	"time"
+)
+
+// ArchiveActivities stores the original files and metadata of every activity of
+// the international account started on or after the day of since in a, skipping
+// activities already archived. Rules set by Filter apply as for syncs.
+func ArchiveActivities(userInfo UserInfo, since time.Time, a *archive.Archive, opts ...Option) (bool, string, error) {
+	o := newOptions(opts...)
+	clientIntl, _ := newClients(userInfo, o)
+	return Mirror(context.Background(), NewGarminSource(clientIntl), NewArchiveTarget(a), garmin.ActivityFilter{StartDate: since}, opts...)
+}
+
+// ArchiveSource reads the activities of a local archive.
+type ArchiveSource struct {
+	archive *archive.Archive
+}
+
+func NewArchiveSource(a *archive.Archive) *ArchiveSource {
+	return &ArchiveSource{archive: a}
+}
+
+// List supports the dates, activity type and limit of filter. Entries are filtered
+// on the index before their metadata is read.
+func (s *ArchiveSource) List(ctx context.Context, filter garmin.ActivityFilter) ([]garmin.ActivityListItem, error) {
+	entries := s.archive.Entries()
+	items := make([]garmin.ActivityListItem, 0, len(entries))
+	// NOTE: entries are oldest first, lists most recent first
+	for i := len(entries) - 1; i >= 0; i-- {
+		if filter.Limit > 0 && len(items) >= filter.Limit {
+			break
+		}
+		entry := entries[i]
+		if !archiveFilterMatches(filter, garmin.ActivityListItem{
+			ActivityId:     entry.ActivityId,
+			ActivityType:   garmin.ActivityType{TypeKey: entry.ActivityType},
+			StartTimeLocal: entry.StartTimeLocal,
+		}) {
+			continue
+		}
+		activity, err := s.archive.Activity(entry.ActivityId)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, activity.ListItem())
+	}
+	return items, nil
+}
+
+func (s *ArchiveSource) Fetch(ctx context.Context, item garmin.ActivityListItem) (garmin.Activity, []garmin.ActivityFile, error) {
+	activity, err := s.archive.Activity(item.ActivityId)
+	if err != nil {
+		return garmin.Activity{}, nil, err
+	}
+	files, err := s.archive.Files(item.ActivityId)
+	if err != nil {
+		return garmin.Activity{}, nil, err
+	}
+	return activity, files, nil
+}
+
+// ArchiveTarget stores activities in a local archive, keyed by the activity id of the source.
+type ArchiveTarget struct {
+	archive *archive.Archive
+}
+
+func NewArchiveTarget(a *archive.Archive) *ArchiveTarget {
+	return &ArchiveTarget{archive: a}
+}
+
+// NOTE: the stored metadata is the full details of the activity
+func (t *ArchiveTarget) needsDetails() bool {
+	return true
+}
+
+func (t *ArchiveTarget) Exists(ctx context.Context, item garmin.ActivityListItem) (bool, error) {
+	return t.archive.Has(item.ActivityId), nil
+}
+
+func (t *ArchiveTarget) Upload(ctx context.Context, activity garmin.Activity, files []garmin.ActivityFile) error {
+	_, stored, err := t.archive.Store(activity, files)
+	if err == nil && !stored {
+		return garmin.ErrDuplicateActivity
+	}
+	return err
+}
+
+// archiveFilterMatches compares the local start day of item to the dates of filter.
+func archiveFilterMatches(filter garmin.ActivityFilter, item garmin.ActivityListItem) bool {
+	if filter.ActivityType != "" && filter.ActivityType != item.ActivityType.TypeKey {
+		return false
+	}
+	if filter.StartDate.IsZero() && filter.EndDate.IsZero() {
+		return true
+	}
+	startTime, err := parseStartTime(item.StartTimeLocal)
+	if err != nil {
+		return false
+	}
+	day := startTime.Format("2006-01-02")
+	if !filter.StartDate.IsZero() && day < filter.StartDate.Format("2006-01-02") {
+		return false
+	}
+	if !filter.EndDate.IsZero() && day > filter.EndDate.Format("2006-01-02") {
+		return false
+	}
+	return true
+}
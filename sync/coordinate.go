@@ -0,0 +1,66 @@
+package sync
+
+import (
+	"fmt"
+	"github.com/yqt/garmin-intl2cn/coord"
+	"github.com/yqt/garmin-intl2cn/fit"
+)
+
+type CoordinateConversion func(lat float64, lng float64) (float64, float64)
+
+const (
+	ConversionWGS84ToGCJ02 = "wgs84-to-gcj02"
+	ConversionGCJ02ToWGS84 = "gcj02-to-wgs84"
+)
+
+// CoordinateConversionByName returns nil for an empty name, and an error for an unknown one.
+func CoordinateConversionByName(name string) (CoordinateConversion, error) {
+	switch name {
+	case "":
+		return nil, nil
+	case ConversionWGS84ToGCJ02:
+		return coord.WGS84ToGCJ02, nil
+	case ConversionGCJ02ToWGS84:
+		return coord.GCJ02ToWGS84, nil
+	}
+	return nil, fmt.Errorf("unknown coordinate conversion: %q", name)
+}
+
+// positionFields lists the latitude/longitude field pairs of each message holding a position.
+var positionFields = map[fit.MesgNum][][2]byte{
+	fit.MesgNumRecord:  {{0, 1}},
+	fit.MesgNumLap:     {{3, 4}, {5, 6}},
+	fit.MesgNumSession: {{3, 4}, {29, 30}, {31, 32}},
+}
+
+func convertFITCoordinates(data []byte, convert CoordinateConversion) ([]byte, error) {
+	file, err := fit.DecodeBytes(data)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range file.Messages {
+		msg := &file.Messages[i]
+		for _, pair := range positionFields[msg.Num] {
+			convertPosition(msg, pair[0], pair[1], convert)
+		}
+	}
+
+	return fit.EncodeBytes(file)
+}
+
+func convertPosition(msg *fit.Message, latNum byte, lngNum byte, convert CoordinateConversion) {
+	latField, lngField := msg.Field(latNum), msg.Field(lngNum)
+	if latField == nil || lngField == nil {
+		return
+	}
+	latSemicircles, latOk := latField.Int()
+	lngSemicircles, lngOk := lngField.Int()
+	if !latOk || !lngOk {
+		return
+	}
+
+	lat, lng := convert(fit.SemicirclesToDegrees(int32(latSemicircles)), fit.SemicirclesToDegrees(int32(lngSemicircles)))
+	latField.Value = fit.DegreesToSemicircles(lat)
+	lngField.Value = fit.DegreesToSemicircles(lng)
+}
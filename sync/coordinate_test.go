@@ -0,0 +1,47 @@
+package sync
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/yqt/garmin-intl2cn/coord"
+	"github.com/yqt/garmin-intl2cn/fit"
+	"io/ioutil"
+	"testing"
+)
+
+func TestConvertFITCoordinates(t *testing.T) {
+	data, err := ioutil.ReadFile("../fit/testdata/activity.fit")
+	assert.Nil(t, err)
+	original, err := fit.DecodeBytes(data)
+	assert.Nil(t, err)
+
+	converted, err := convertFITCoordinates(data, coord.WGS84ToGCJ02)
+	assert.Nil(t, err)
+	convertedFile, err := fit.DecodeBytes(converted)
+	assert.Nil(t, err)
+
+	originalRecords, convertedRecords := original.Records(), convertedFile.Records()
+	assert.Len(t, convertedRecords, len(originalRecords))
+	for i := range originalRecords {
+		lat, lng := coord.WGS84ToGCJ02(originalRecords[i].Position.Lat, originalRecords[i].Position.Long)
+		assert.InDelta(t, lat, convertedRecords[i].Position.Lat, 1e-6)
+		assert.InDelta(t, lng, convertedRecords[i].Position.Long, 1e-6)
+		assert.Equal(t, originalRecords[i].HeartRate, convertedRecords[i].HeartRate)
+	}
+
+	lat, lng := coord.WGS84ToGCJ02(original.Laps()[0].EndPosition.Lat, original.Laps()[0].EndPosition.Long)
+	assert.InDelta(t, lat, convertedFile.Laps()[0].EndPosition.Lat, 1e-6)
+	assert.InDelta(t, lng, convertedFile.Laps()[0].EndPosition.Long, 1e-6)
+
+	lat, lng = coord.WGS84ToGCJ02(original.Sessions()[0].StartPosition.Lat, original.Sessions()[0].StartPosition.Long)
+	assert.InDelta(t, lat, convertedFile.Sessions()[0].StartPosition.Lat, 1e-6)
+	assert.InDelta(t, lng, convertedFile.Sessions()[0].StartPosition.Long, 1e-6)
+
+	restored, err := convertFITCoordinates(converted, coord.GCJ02ToWGS84)
+	assert.Nil(t, err)
+	restoredFile, err := fit.DecodeBytes(restored)
+	assert.Nil(t, err)
+	for i, record := range restoredFile.Records() {
+		assert.InDelta(t, originalRecords[i].Position.Lat, record.Position.Lat, 1e-6)
+		assert.InDelta(t, originalRecords[i].Position.Long, record.Position.Long, 1e-6)
+	}
+}
@@ -0,0 +1,107 @@
+package sync
+
+import (
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"github.com/yqt/garmin-intl2cn/garmin"
+	"strings"
+)
+
+// SynchronizeCourses copies the courses of the international account to the CN
+// account, keeping their name, description and activity type. Copies are recorded
+// in the history set by SyncHistory and matched by their recorded id first, then by name.
+func SynchronizeCourses(userInfo UserInfo, opts ...Option) (bool, string, error) {
+	o := newOptions(opts...)
+	clientIntl, clientCn := newClients(userInfo, o)
+
+	if err := clientIntl.Auth(false); err != nil {
+		return false, "", err
+	}
+	if err := clientCn.Auth(false); err != nil {
+		return false, "", err
+	}
+	if err := checkSameOwner(clientIntl, clientCn, o); err != nil {
+		return false, "", err
+	}
+
+	intlCourses, err := clientIntl.GetCourses()
+	if err != nil {
+		return false, "", err
+	}
+	cnCourses, err := clientCn.GetCourses()
+	if err != nil {
+		return false, "", err
+	}
+
+	succeeded := make([]string, 0)
+	failed := make([]string, 0)
+	skipped := make([]string, 0)
+	for _, course := range intlCourses {
+		var entry *HistoryEntry
+		if e, ok := lookupHistory(o.history, HistoryKindCourse, course.CourseId); ok {
+			entry = &e
+		}
+		if matched, ok := matchCourse(course, entry, cnCourses); ok {
+			if entry == nil || entry.TargetId != matched.CourseId {
+				recordHistory(o.history, HistoryKindCourse, course.CourseId, matched.CourseId, course.CourseName)
+			}
+			skipped = append(skipped, course.CourseName)
+			continue
+		}
+
+		created, err := copyCourse(clientIntl, clientCn, course)
+		if err != nil {
+			logrus.WithFields(logrus.Fields{
+				"courseId": course.CourseId,
+				"err":      err,
+			}).Error("course copy failed")
+			failed = append(failed, course.CourseName)
+			continue
+		}
+		recordHistory(o.history, HistoryKindCourse, course.CourseId, created.CourseId, course.CourseName)
+		succeeded = append(succeeded, course.CourseName)
+	}
+
+	suc := true
+	if len(succeeded) == 0 && len(failed) != 0 {
+		suc = false
+	}
+	return suc, fmt.Sprintf(
+		"courses[%s] succeeded. courses[%s] failed. courses[%s] skipped.",
+		strings.Join(succeeded, ", "), strings.Join(failed, ", "), strings.Join(skipped, ", ")), nil
+}
+
+// matchCourse finds the copy of course among candidates, by the recorded history
+// entry if any and by name otherwise, so renamed copies are still found.
+func matchCourse(course garmin.Course, entry *HistoryEntry, candidates []garmin.Course) (garmin.Course, bool) {
+	if entry != nil {
+		for _, candidate := range candidates {
+			if candidate.CourseId == entry.TargetId {
+				return candidate, true
+			}
+		}
+	}
+	for _, candidate := range candidates {
+		if candidate.CourseName == course.CourseName {
+			return candidate, true
+		}
+	}
+	return garmin.Course{}, false
+}
+
+// copyCourse moves the track through GPX, since the import of the target only
+// keeps the points, and sets the name, description and type of the source again.
+func copyCourse(source *garmin.Client, target *garmin.Client, course garmin.Course) (garmin.Course, error) {
+	file, err := source.DownloadCourse(course.CourseId, garmin.FormatGPX)
+	if err != nil {
+		return garmin.Course{}, err
+	}
+	imported, err := target.ImportCourse(file.FileName, file.Reader())
+	if err != nil {
+		return garmin.Course{}, err
+	}
+	imported.CourseName = course.CourseName
+	imported.Description = course.Description
+	imported.ActivityType = course.ActivityType
+	return target.CreateCourse(imported)
+}
@@ -0,0 +1,29 @@
+package sync
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/yqt/garmin-intl2cn/garmin"
+	"testing"
+)
+
+func TestMatchCourse(t *testing.T) {
+	candidates := []garmin.Course{
+		{CourseId: 1, CourseName: "Lakeside Loop (CN)"},
+		{CourseId: 2, CourseName: "Bund Run"},
+	}
+	source := garmin.Course{CourseId: 100, CourseName: "Lakeside Loop"}
+
+	_, ok := matchCourse(source, nil, candidates)
+	assert.False(t, ok)
+
+	// the recorded copy is found even though it was renamed on the target
+	matched, ok := matchCourse(source, &HistoryEntry{Kind: HistoryKindCourse, SourceId: 100, TargetId: 1}, candidates)
+	assert.True(t, ok)
+	assert.Equal(t, int64(1), matched.CourseId)
+
+	// a deleted copy falls back to the name
+	matched, ok = matchCourse(garmin.Course{CourseId: 101, CourseName: "Bund Run"},
+		&HistoryEntry{Kind: HistoryKindCourse, SourceId: 101, TargetId: 3}, candidates)
+	assert.True(t, ok)
+	assert.Equal(t, int64(2), matched.CourseId)
+}
@@ -0,0 +1,226 @@
+package sync
+
+import (
+	"errors"
+	"fmt"
+	"github.com/yqt/garmin-intl2cn/garmin"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	FilterFieldType         = "type"
+	FilterFieldDuration     = "duration"
+	FilterFieldDistance     = "distance"
+	FilterFieldName         = "name"
+	FilterFieldPrivacy      = "privacy"
+	FilterFieldManufacturer = "manufacturer"
+)
+
+var ErrInvalidRule = errors.New("invalid filter rule")
+
+// Rule decides whether an activity is synced. Rules are written as
+// "<include|exclude> <field> <operator> <value>", for example
+//
+//	include type in running,cycling
+//	exclude duration < 10m
+//	exclude distance < 1km
+//	exclude name ~ (?i)^test
+//	exclude privacy = private
+//	include manufacturer = garmin
+//
+// Durations use time.ParseDuration, distances are meters unless suffixed with km.
+// Text fields compare case-insensitively, "~" matches a regular expression.
+type Rule struct {
+	Include bool
+	Field   string
+	Op      string
+	Value   string
+
+	text    string
+	number  float64
+	values  []string
+	pattern *regexp.Regexp
+}
+
+var numericFields = map[string]bool{
+	FilterFieldDuration: true,
+	FilterFieldDistance: true,
+}
+
+var textFields = map[string]bool{
+	FilterFieldType:         true,
+	FilterFieldName:         true,
+	FilterFieldPrivacy:      true,
+	FilterFieldManufacturer: true,
+}
+
+var rulePattern = regexp.MustCompile(`^\s*(\S+)\s+(\S+)\s+(\S+)\s+(.*?)\s*$`)
+
+func ParseRule(text string) (Rule, error) {
+	parts := rulePattern.FindStringSubmatch(text)
+	if parts == nil {
+		return Rule{}, fmt.Errorf("%w: %q", ErrInvalidRule, text)
+	}
+	rule := Rule{
+		Field: strings.ToLower(parts[2]),
+		Op:    strings.ToLower(parts[3]),
+		// NOTE: the value keeps its inner spacing, e.g. for names
+		Value: parts[4],
+		text:  strings.TrimSpace(text),
+	}
+	switch strings.ToLower(parts[1]) {
+	case "include":
+		rule.Include = true
+	case "exclude":
+	default:
+		return Rule{}, fmt.Errorf("%w: %q must start with include or exclude", ErrInvalidRule, text)
+	}
+
+	var err error
+	switch {
+	case numericFields[rule.Field]:
+		switch rule.Op {
+		case "=", "!=", "<", "<=", ">", ">=":
+		default:
+			return Rule{}, fmt.Errorf("%w: %q: operator %s is not supported by %s", ErrInvalidRule, text, rule.Op, rule.Field)
+		}
+		if rule.Field == FilterFieldDuration {
+			var d time.Duration
+			d, err = time.ParseDuration(rule.Value)
+			rule.number = d.Seconds()
+		} else {
+			rule.number, err = parseDistance(rule.Value)
+		}
+	case textFields[rule.Field]:
+		switch rule.Op {
+		case "=", "!=":
+		case "in":
+			for _, v := range strings.Split(rule.Value, ",") {
+				rule.values = append(rule.values, strings.TrimSpace(v))
+			}
+		case "~":
+			rule.pattern, err = regexp.Compile(rule.Value)
+		default:
+			return Rule{}, fmt.Errorf("%w: %q: operator %s is not supported by %s", ErrInvalidRule, text, rule.Op, rule.Field)
+		}
+	default:
+		return Rule{}, fmt.Errorf("%w: %q: unknown field %s", ErrInvalidRule, text, rule.Field)
+	}
+	if err != nil {
+		return Rule{}, fmt.Errorf("%w: %q: %v", ErrInvalidRule, text, err)
+	}
+	return rule, nil
+}
+
+func ParseRules(texts []string) ([]Rule, error) {
+	rules := make([]Rule, 0, len(texts))
+	for _, text := range texts {
+		rule, err := ParseRule(text)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+func (r Rule) String() string {
+	return r.text
+}
+
+// Matches reports whether the condition of the rule holds for activity,
+// regardless of whether the rule includes or excludes.
+func (r Rule) Matches(activity garmin.ActivityListItem) bool {
+	if numericFields[r.Field] {
+		var v float64
+		if r.Field == FilterFieldDuration {
+			v = activity.Duration
+		} else {
+			v = activity.Distance
+		}
+		switch r.Op {
+		case "=":
+			return v == r.number
+		case "!=":
+			return v != r.number
+		case "<":
+			return v < r.number
+		case "<=":
+			return v <= r.number
+		case ">":
+			return v > r.number
+		case ">=":
+			return v >= r.number
+		}
+		return false
+	}
+
+	var v string
+	switch r.Field {
+	case FilterFieldType:
+		v = activity.ActivityType.TypeKey
+	case FilterFieldName:
+		v = activity.ActivityName
+	case FilterFieldPrivacy:
+		v = activity.Privacy.TypeKey
+	case FilterFieldManufacturer:
+		v = activity.Manufacturer
+	}
+	switch r.Op {
+	case "=":
+		return strings.EqualFold(v, r.Value)
+	case "!=":
+		return !strings.EqualFold(v, r.Value)
+	case "in":
+		for _, value := range r.values {
+			if strings.EqualFold(v, value) {
+				return true
+			}
+		}
+		return false
+	case "~":
+		return r.pattern.MatchString(v)
+	}
+	return false
+}
+
+// FilterActivity returns whether activity passes rules and, if not, why. An activity
+// is synced when it matches no exclude rule and, if there are include rules, at least one of them.
+func FilterActivity(rules []Rule, activity garmin.ActivityListItem) (bool, string) {
+	hasInclude, included := false, false
+	for _, rule := range rules {
+		if !rule.Include {
+			if rule.Matches(activity) {
+				return false, fmt.Sprintf("matched %q", rule.text)
+			}
+			continue
+		}
+		hasInclude = true
+		if !included && rule.Matches(activity) {
+			included = true
+		}
+	}
+	if hasInclude && !included {
+		return false, "matched no include rule"
+	}
+	return true, ""
+}
+
+func parseDistance(value string) (float64, error) {
+	value = strings.ToLower(value)
+	scale := 1.0
+	switch {
+	case strings.HasSuffix(value, "km"):
+		value, scale = strings.TrimSuffix(value, "km"), 1000
+	case strings.HasSuffix(value, "m"):
+		value = strings.TrimSuffix(value, "m")
+	}
+	v, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil {
+		return 0, err
+	}
+	return v * scale, nil
+}
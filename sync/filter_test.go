@@ -0,0 +1,106 @@
+package sync
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/yqt/garmin-intl2cn/garmin"
+	"testing"
+)
+
+func newFilterActivity() garmin.ActivityListItem {
+	return garmin.ActivityListItem{
+		ActivityName: "Morning Run in Shanghai",
+		ActivityType: garmin.ActivityType{TypeKey: "running"},
+		Duration:     1800,
+		Distance:     5200,
+		Manufacturer: "GARMIN",
+		Privacy:      garmin.AccessControl{TypeKey: "public"},
+	}
+}
+
+func TestParseRule(t *testing.T) {
+	rule, err := ParseRule("  include type in running, cycling ")
+	assert.Nil(t, err)
+	assert.True(t, rule.Include)
+	assert.Equal(t, FilterFieldType, rule.Field)
+	assert.Equal(t, "in", rule.Op)
+	assert.Equal(t, "running, cycling", rule.Value)
+	assert.Equal(t, "include type in running, cycling", rule.String())
+
+	rule, err = ParseRule("exclude name ~ (?i)^morning run")
+	assert.Nil(t, err)
+	assert.False(t, rule.Include)
+	assert.Equal(t, "(?i)^morning run", rule.Value)
+
+	for _, text := range []string{
+		"exclude duration",
+		"skip duration < 10m",
+		"exclude pace < 5m",
+		"exclude duration ~ 10m",
+		"exclude duration < 10 minutes",
+		"exclude distance < far",
+		"exclude type > running",
+		"exclude name ~ (",
+	} {
+		_, err := ParseRule(text)
+		assert.True(t, errors.Is(err, ErrInvalidRule), text)
+	}
+}
+
+func TestRule_Matches(t *testing.T) {
+	activity := newFilterActivity()
+
+	cases := map[string]bool{
+		"exclude duration < 10m":               false,
+		"exclude duration >= 30m":              true,
+		"exclude duration = 30m":               true,
+		"exclude distance < 5km":               false,
+		"exclude distance > 5000":              true,
+		"exclude distance <= 5200m":            true,
+		"exclude type = Running":               true,
+		"exclude type != running":              false,
+		"exclude type in cycling,swimming":     false,
+		"exclude type in cycling, running":     true,
+		"exclude name ~ (?i)morning run":       true,
+		"exclude name ~ ^Evening":              false,
+		"exclude privacy = private":            false,
+		"exclude manufacturer = garmin":        true,
+		"exclude manufacturer in suunto,coros": false,
+	}
+	for text, expected := range cases {
+		rule, err := ParseRule(text)
+		assert.Nil(t, err, text)
+		assert.Equal(t, expected, rule.Matches(activity), text)
+	}
+}
+
+func TestFilterActivity(t *testing.T) {
+	activity := newFilterActivity()
+
+	ok, reason := FilterActivity(nil, activity)
+	assert.True(t, ok)
+	assert.Equal(t, "", reason)
+
+	rules, err := ParseRules([]string{
+		"include type in running,cycling",
+		"exclude duration < 10m",
+	})
+	assert.Nil(t, err)
+	ok, _ = FilterActivity(rules, activity)
+	assert.True(t, ok)
+
+	short := activity
+	short.Duration = 300
+	ok, reason = FilterActivity(rules, short)
+	assert.False(t, ok)
+	assert.Equal(t, `matched "exclude duration < 10m"`, reason)
+
+	swim := activity
+	swim.ActivityType.TypeKey = "lap_swimming"
+	ok, reason = FilterActivity(rules, swim)
+	assert.False(t, ok)
+	assert.Equal(t, "matched no include rule", reason)
+
+	_, err = ParseRules([]string{"exclude duration < 10m", "exclude"})
+	assert.NotNil(t, err)
+}
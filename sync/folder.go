@@ -0,0 +1,239 @@
+package sync
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"github.com/yqt/garmin-intl2cn/fit"
+	"github.com/yqt/garmin-intl2cn/garmin"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	AccountIntl = "intl"
+	AccountCn   = "cn"
+
+	// importSettleTime skips files modified more recently, which may still be copied
+	importSettleTime = 10 * time.Second
+)
+
+var ErrUnknownAccount = errors.New("unknown account")
+
+// LocalFile is an activity file found in an import folder.
+type LocalFile struct {
+	Path string
+	Hash string
+	// StartTime is only known for FIT files
+	StartTime time.Time
+	File      garmin.ActivityFile
+}
+
+// ImportFolder uploads the FIT, TCX and GPX files found in dir and its sub
+// directories to accounts, intl and/or cn. Files already recorded in ledger for
+// an account, by hash or FIT start time, are skipped, as are FIT files which are
+// not activities, e.g. the monitoring files of a watch. Files uploaded to cn go
+// through the Transformers option first, like the activities sync uploads there;
+// files uploaded to intl are kept as recorded by the device.
+func ImportFolder(userInfo UserInfo, dir string, accounts []string, ledger *ImportLedger, opts ...Option) (bool, string, error) {
+	o := newOptions(opts...)
+	clientIntl, clientCn := newClients(userInfo, o)
+	clients := make(map[string]*garmin.Client)
+	for _, account := range accounts {
+		switch account {
+		case AccountIntl:
+			clients[account] = clientIntl
+		case AccountCn:
+			clients[account] = clientCn
+		default:
+			return false, "", fmt.Errorf("%w: %s", ErrUnknownAccount, account)
+		}
+	}
+
+	files, err := scanFolder(dir, time.Now())
+	if err != nil {
+		return false, "", err
+	}
+
+	var transformer Transformer
+	if len(o.transformers) > 0 {
+		transformer = Chain(o.transformers...)
+	}
+
+	succeeded := make([]string, 0)
+	failed := make([]string, 0)
+	skipped := 0
+	for _, account := range accounts {
+		client := clients[account]
+		authed := false
+		for _, file := range files {
+			if ledger.Imported(file.Hash, file.StartTime, account) {
+				skipped++
+				continue
+			}
+			if !authed {
+				if err := client.Auth(false); err != nil {
+					return false, "", err
+				}
+				authed = true
+			}
+
+			upload, err := importFile(file, account, transformer)
+			if err != nil {
+				logrus.WithFields(logrus.Fields{
+					"path":    file.Path,
+					"account": account,
+					"err":     err,
+				}).Error("activity import transform failed")
+				failed = append(failed, account+":"+file.File.FileName)
+				continue
+			}
+
+			_, err = client.UploadActivity(upload.FileName, upload.Reader())
+			if err != nil && !errors.Is(err, garmin.ErrDuplicateActivity) {
+				logrus.WithFields(logrus.Fields{
+					"path":    file.Path,
+					"account": account,
+					"err":     err,
+				}).Error("activity import failed")
+				failed = append(failed, account+":"+file.File.FileName)
+				continue
+			}
+			if err := ledger.Record(file.Hash, file.File.FileName, file.StartTime, account); err != nil {
+				logrus.WithFields(logrus.Fields{
+					"path": file.Path,
+					"err":  err,
+				}).Error("import ledger update failed")
+			}
+			succeeded = append(succeeded, account+":"+file.File.FileName)
+		}
+	}
+
+	suc := true
+	if len(succeeded) == 0 && len(failed) != 0 {
+		suc = false
+	}
+	return suc, fmt.Sprintf(
+		"files[%s] imported. files[%s] failed. %d skipped.",
+		strings.Join(succeeded, ", "), strings.Join(failed, ", "), skipped), nil
+}
+
+// WatchFolder runs ImportFolder every interval until ctx is done. The folder is
+// polled rather than watched through inotify, which also works on mounted USB
+// storage and other systems.
+func WatchFolder(ctx context.Context, userInfo UserInfo, dir string, accounts []string, ledger *ImportLedger, interval time.Duration, opts ...Option) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		suc, msg, err := ImportFolder(userInfo, dir, accounts, ledger, opts...)
+		logrus.WithFields(logrus.Fields{
+			"dir": dir,
+			"suc": suc,
+			"msg": msg,
+			"err": err,
+		}).Info("folder import result")
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// importFile returns the file to upload to account, run through transformer
+// for cn.
+func importFile(file LocalFile, account string, transformer Transformer) (garmin.ActivityFile, error) {
+	if account != AccountCn || transformer == nil {
+		return file.File, nil
+	}
+	_, transformed, err := transformActivity(transformer, garmin.Activity{}, []garmin.ActivityFile{file.File})
+	if err != nil {
+		return garmin.ActivityFile{}, err
+	}
+	return transformed[0], nil
+}
+
+// scanFolder reads the activity files of dir, leaving out files modified less
+// than importSettleTime before now. Unreadable files and directories are
+// logged and skipped.
+func scanFolder(dir string, now time.Time) ([]LocalFile, error) {
+	if _, err := os.Stat(dir); err != nil {
+		return nil, err
+	}
+
+	files := make([]LocalFile, 0)
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			logrus.WithFields(logrus.Fields{
+				"path": path,
+				"err":  err,
+			}).Warn("import folder entry unreadable, skipped")
+			if info != nil && info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() || now.Sub(info.ModTime()) < importSettleTime {
+			return nil
+		}
+		switch garmin.FormatOfFileName(path) {
+		case garmin.FormatFIT, garmin.FormatTCX, garmin.FormatGPX:
+		default:
+			return nil
+		}
+
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			logrus.WithFields(logrus.Fields{
+				"path": path,
+				"err":  err,
+			}).Warn("import file unreadable, skipped")
+			return nil
+		}
+		file, ok := newLocalFile(path, data)
+		if !ok {
+			logrus.WithFields(logrus.Fields{
+				"path": path,
+			}).Debug("not an activity file, skipped")
+			return nil
+		}
+		files = append(files, file)
+		return nil
+	})
+	return files, err
+}
+
+func newLocalFile(path string, data []byte) (LocalFile, bool) {
+	sum := sha256.Sum256(data)
+	file := LocalFile{
+		Path: path,
+		Hash: hex.EncodeToString(sum[:]),
+		File: garmin.NewActivityFile(filepath.Base(path), data),
+	}
+	if file.File.Format != garmin.FormatFIT {
+		return file, true
+	}
+
+	decoded, err := fit.DecodeBytes(data)
+	if err != nil {
+		return file, false
+	}
+	fileId, ok := decoded.FileID()
+	if ok && fileId.Type != fit.FileTypeActivity {
+		return file, false
+	}
+	if sessions := decoded.Sessions(); len(sessions) > 0 && !sessions[0].StartTime.IsZero() {
+		file.StartTime = sessions[0].StartTime
+	} else if ok {
+		file.StartTime = fileId.TimeCreated
+	}
+	return file, true
+}
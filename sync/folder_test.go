@@ -0,0 +1,92 @@
+package sync
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/yqt/garmin-intl2cn/fit"
+	"github.com/yqt/garmin-intl2cn/garmin"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func testFITFile(t *testing.T, fileType uint8, startTime time.Time) []byte {
+	file := &fit.File{
+		Messages: []fit.Message{{
+			Num: fit.MesgNumFileID,
+			Fields: []fit.Field{
+				{Num: 0, Type: fit.BaseTypeEnum, Value: fileType},
+				{Num: 4, Type: fit.BaseTypeUint32, Value: fit.TimeToFIT(startTime)},
+			},
+		}},
+	}
+	if fileType == fit.FileTypeActivity {
+		file.Messages = append(file.Messages, fit.Message{
+			Num:      fit.MesgNumSession,
+			LocalNum: 1,
+			Fields: []fit.Field{
+				{Num: fit.FieldNumTimestamp, Type: fit.BaseTypeUint32, Value: fit.TimeToFIT(startTime.Add(time.Hour))},
+				{Num: 2, Type: fit.BaseTypeUint32, Value: fit.TimeToFIT(startTime)},
+			},
+		})
+	}
+	data, err := fit.EncodeBytes(file)
+	assert.Nil(t, err)
+	return data
+}
+
+func TestScanFolder(t *testing.T) {
+	dir, err := ioutil.TempDir("", "import")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	startTime := time.Date(2021, 1, 2, 0, 30, 0, 0, time.UTC)
+
+	assert.Nil(t, os.MkdirAll(filepath.Join(dir, "GARMIN", "Activity"), 0755))
+	assert.Nil(t, os.MkdirAll(filepath.Join(dir, "GARMIN", "Monitor"), 0755))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "GARMIN", "Activity", "B12A0830.FIT"), testFITFile(t, fit.FileTypeActivity, startTime), 0644))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "GARMIN", "Monitor", "B12A0000.FIT"), testFITFile(t, 32, startTime), 0644))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "ride.gpx"), []byte(`<?xml version="1.0"?><gpx></gpx>`), 0644))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "notes.txt"), []byte("notes"), 0644))
+	// an unreadable file is skipped without failing the scan
+	assert.Nil(t, os.Symlink(filepath.Join(dir, "missing.fit"), filepath.Join(dir, "broken.fit")))
+
+	// files are only picked up once they are no longer written
+	files, err := scanFolder(dir, time.Now())
+	assert.Nil(t, err)
+	assert.Len(t, files, 0)
+
+	files, err = scanFolder(dir, time.Now().Add(time.Minute))
+	assert.Nil(t, err)
+	assert.Len(t, files, 2)
+	assert.Equal(t, "B12A0830.FIT", files[0].File.FileName)
+	assert.True(t, files[0].StartTime.Equal(startTime))
+	assert.Len(t, files[0].Hash, 64)
+	assert.Equal(t, "ride.gpx", files[1].File.FileName)
+	assert.True(t, files[1].StartTime.IsZero())
+}
+
+func TestScanFolder_MissingDir(t *testing.T) {
+	_, err := scanFolder(filepath.Join(os.TempDir(), "import-missing"), time.Now())
+	assert.NotNil(t, err)
+}
+
+func TestImportFile(t *testing.T) {
+	file := LocalFile{Path: "ride.gpx", File: garmin.NewActivityFile("ride.gpx", []byte("<gpx></gpx>"))}
+	transformer := TransformerFunc(func(file garmin.ActivityFile, activity garmin.Activity) (garmin.ActivityFile, garmin.Activity, error) {
+		return garmin.NewActivityFile(file.FileName, []byte("<gpx><trk></trk></gpx>")), activity, nil
+	})
+
+	upload, err := importFile(file, AccountIntl, transformer)
+	assert.Nil(t, err)
+	assert.Equal(t, file.File.Data, upload.Data)
+
+	upload, err = importFile(file, AccountCn, transformer)
+	assert.Nil(t, err)
+	assert.Equal(t, "ride.gpx", upload.FileName)
+	assert.Equal(t, []byte("<gpx><trk></trk></gpx>"), upload.Data)
+
+	upload, err = importFile(file, AccountCn, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, file.File.Data, upload.Data)
+}
@@ -0,0 +1,143 @@
+package sync
+
+import (
+	"github.com/sirupsen/logrus"
+	"github.com/yqt/garmin-intl2cn/garmin"
+	"sync"
+)
+
+const (
+	HistoryKindGear = "gear"
+
+	gearStatusRetired = "retired"
+)
+
+// gearSource and gearTarget are the parts of garmin.Client used by gearMapping.
+type gearSource interface {
+	GetActivityGear(activityId int64) ([]garmin.Gear, error)
+}
+
+type gearTarget interface {
+	GetGear() ([]garmin.Gear, error)
+	CreateGear(gear garmin.Gear) (garmin.Gear, error)
+	LinkGear(gearUuid string, activityId int64) error
+}
+
+// gearMapping links the gear of synced activities to the matching gear of the
+// target account. Gear is matched through the history set by SyncHistory first,
+// then by name, and is created on the target account when neither matches.
+type gearMapping struct {
+	source  gearSource
+	target  gearTarget
+	history *History
+
+	mu sync.Mutex
+	// targetGear is loaded on first use
+	targetGear []garmin.Gear
+}
+
+func newGearMapping(source gearSource, target gearTarget, history *History) *gearMapping {
+	return &gearMapping{
+		source:  source,
+		target:  target,
+		history: history,
+	}
+}
+
+// linkGear makes target link the gear of the activities it uploads from source,
+// when both are garmin accounts.
+func linkGear(source Source, target Target, history *History) {
+	garminSource, ok := source.(*GarminSource)
+	if !ok {
+		return
+	}
+	garminTarget, ok := target.(*GarminTarget)
+	if !ok {
+		return
+	}
+	garminTarget.gear = newGearMapping(garminSource.client, garminTarget.client, history)
+}
+
+// link adds the gear of the source activity to the uploaded target activity.
+func (m *gearMapping) link(sourceActivityId int64, targetActivityId int64) error {
+	gear, err := m.source.GetActivityGear(sourceActivityId)
+	if err != nil {
+		return err
+	}
+	for _, g := range gear {
+		targetGear, err := m.targetFor(g)
+		if err != nil {
+			return err
+		}
+		if err = m.target.LinkGear(targetGear.Uuid, targetActivityId); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *gearMapping) targetFor(gear garmin.Gear) (garmin.Gear, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.targetGear == nil {
+		targetGear, err := m.target.GetGear()
+		if err != nil {
+			return garmin.Gear{}, err
+		}
+		m.targetGear = targetGear
+	}
+
+	var entry *HistoryEntry
+	if e, ok := lookupHistory(m.history, HistoryKindGear, gear.GearPk); ok {
+		entry = &e
+	}
+	if targetGear, ok := matchGear(gear, entry, m.targetGear); ok {
+		if entry == nil || entry.TargetId != targetGear.GearPk {
+			recordHistory(m.history, HistoryKindGear, gear.GearPk, targetGear.GearPk, gear.Name())
+		}
+		return targetGear, nil
+	}
+
+	created, err := m.target.CreateGear(gear)
+	if err != nil {
+		return garmin.Gear{}, err
+	}
+	logrus.WithFields(logrus.Fields{
+		"gearPk": gear.GearPk,
+		"name":   gear.Name(),
+	}).Info("gear created on target account")
+	m.targetGear = append(m.targetGear, created)
+	recordHistory(m.history, HistoryKindGear, gear.GearPk, created.GearPk, gear.Name())
+	return created, nil
+}
+
+// matchGear finds the copy of gear among candidates, by the recorded history entry
+// if any and by name otherwise, preferring active gear over retired gear of the
+// same name. A recorded copy which was deleted since falls back to matching by name.
+func matchGear(gear garmin.Gear, entry *HistoryEntry, candidates []garmin.Gear) (garmin.Gear, bool) {
+	if entry != nil {
+		for _, candidate := range candidates {
+			if candidate.GearPk == entry.TargetId {
+				return candidate, true
+			}
+		}
+	}
+
+	var retired *garmin.Gear
+	for i, candidate := range candidates {
+		if candidate.Name() != gear.Name() {
+			continue
+		}
+		if candidate.GearStatusName != gearStatusRetired {
+			return candidate, true
+		}
+		if retired == nil {
+			retired = &candidates[i]
+		}
+	}
+	if retired != nil {
+		return *retired, true
+	}
+	return garmin.Gear{}, false
+}
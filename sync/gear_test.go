@@ -0,0 +1,127 @@
+package sync
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/yqt/garmin-intl2cn/garmin"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMatchGear(t *testing.T) {
+	candidates := []garmin.Gear{
+		{Uuid: "cn-1", GearPk: 1, DisplayName: "Daily Trainers"},
+		{Uuid: "cn-2", GearPk: 2, DisplayName: "Road Bike"},
+	}
+	source := garmin.Gear{GearPk: 100, DisplayName: "Road Bike"}
+
+	matched, ok := matchGear(source, nil, candidates)
+	assert.True(t, ok)
+	assert.Equal(t, "cn-2", matched.Uuid)
+
+	// a recorded copy wins over the name, since the gear may have been renamed
+	matched, ok = matchGear(source, &HistoryEntry{Kind: HistoryKindGear, SourceId: 100, TargetId: 1}, candidates)
+	assert.True(t, ok)
+	assert.Equal(t, "cn-1", matched.Uuid)
+
+	// a deleted copy falls back to the name
+	matched, ok = matchGear(source, &HistoryEntry{Kind: HistoryKindGear, SourceId: 100, TargetId: 3}, candidates)
+	assert.True(t, ok)
+	assert.Equal(t, "cn-2", matched.Uuid)
+
+	_, ok = matchGear(garmin.Gear{GearPk: 101, DisplayName: "Trail Shoes"}, nil, candidates)
+	assert.False(t, ok)
+
+	// active gear wins over retired gear of the same name
+	candidates = append([]garmin.Gear{{Uuid: "cn-0", GearPk: 4, DisplayName: "Road Bike", GearStatusName: "retired"}}, candidates...)
+	matched, ok = matchGear(source, nil, candidates)
+	assert.True(t, ok)
+	assert.Equal(t, "cn-2", matched.Uuid)
+	matched, ok = matchGear(source, nil, candidates[:1])
+	assert.True(t, ok)
+	assert.Equal(t, "cn-0", matched.Uuid)
+}
+
+type fakeGearClient struct {
+	activityGear map[int64][]garmin.Gear
+	gear         []garmin.Gear
+	created      []garmin.Gear
+	links        map[int64][]string
+}
+
+func (c *fakeGearClient) GetActivityGear(activityId int64) ([]garmin.Gear, error) {
+	return c.activityGear[activityId], nil
+}
+
+func (c *fakeGearClient) GetGear() ([]garmin.Gear, error) {
+	return c.gear, nil
+}
+
+func (c *fakeGearClient) CreateGear(gear garmin.Gear) (garmin.Gear, error) {
+	gear.GearPk = int64(1000 + len(c.created))
+	gear.Uuid = fmt.Sprintf("created-%d", gear.GearPk)
+	c.created = append(c.created, gear)
+	return gear, nil
+}
+
+func (c *fakeGearClient) LinkGear(gearUuid string, activityId int64) error {
+	c.links[activityId] = append(c.links[activityId], gearUuid)
+	return nil
+}
+
+func TestGearMapping_Link(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gear")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	history, err := NewHistory(filepath.Join(dir, "history.json"))
+	assert.Nil(t, err)
+
+	source := &fakeGearClient{activityGear: map[int64][]garmin.Gear{
+		1: {{GearPk: 100, DisplayName: "Road Bike"}, {GearPk: 101, DisplayName: "Trail Shoes"}},
+		2: {{GearPk: 101, DisplayName: "Trail Shoes"}},
+	}}
+	target := &fakeGearClient{
+		gear:  []garmin.Gear{{Uuid: "cn-2", GearPk: 2, DisplayName: "Road Bike"}},
+		links: make(map[int64][]string),
+	}
+	mapping := newGearMapping(source, target, history)
+
+	assert.Nil(t, mapping.link(1, 11))
+	assert.Equal(t, []string{"cn-2", "created-1000"}, target.links[11])
+	assert.Len(t, target.created, 1)
+
+	// created gear is reused rather than created again
+	assert.Nil(t, mapping.link(2, 12))
+	assert.Equal(t, []string{"created-1000"}, target.links[12])
+	assert.Len(t, target.created, 1)
+
+	entry, ok := history.Lookup(HistoryKindGear, 100)
+	assert.True(t, ok)
+	assert.Equal(t, int64(2), entry.TargetId)
+	entry, ok = history.Lookup(HistoryKindGear, 101)
+	assert.True(t, ok)
+	assert.Equal(t, int64(1000), entry.TargetId)
+}
+
+func TestGearMapping_TargetForRenamed(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gear")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	history, err := NewHistory(filepath.Join(dir, "history.json"))
+	assert.Nil(t, err)
+	assert.Nil(t, history.Record(HistoryKindGear, 100, 2, "Road Bike"))
+
+	target := &fakeGearClient{gear: []garmin.Gear{
+		{Uuid: "cn-1", GearPk: 1, DisplayName: "Road Bike"},
+		{Uuid: "cn-2", GearPk: 2, DisplayName: "Race Bike"},
+	}}
+	mapping := newGearMapping(&fakeGearClient{}, target, history)
+
+	// the recorded copy is used even though it was renamed on the target account
+	gear, err := mapping.targetFor(garmin.Gear{GearPk: 100, DisplayName: "Road Bike"})
+	assert.Nil(t, err)
+	assert.Equal(t, "cn-2", gear.Uuid)
+	assert.Len(t, target.created, 0)
+}
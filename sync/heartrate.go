@@ -0,0 +1,34 @@
+package sync
+
+import (
+	"github.com/yqt/garmin-intl2cn/fit"
+	"regexp"
+)
+
+var (
+	tcxHeartRatePattern = regexp.MustCompile(`(?s)[ \t]*<(?:\w+:)?(?:HeartRateBpm|AverageHeartRateBpm|MaximumHeartRateBpm)\b[^>]*>.*?</(?:\w+:)?(?:HeartRateBpm|AverageHeartRateBpm|MaximumHeartRateBpm)>\r?\n?`)
+	gpxHeartRatePattern = regexp.MustCompile(`(?s)[ \t]*<(?:\w+:)?hr>.*?</(?:\w+:)?hr>\r?\n?`)
+)
+
+// heartRateFields lists the heart rate sample and average/max fields of each message.
+var heartRateFields = map[fit.MesgNum][]byte{
+	fit.MesgNumRecord:  {3},
+	fit.MesgNumLap:     {15, 16},
+	fit.MesgNumSession: {16, 17},
+}
+
+func removeFITHeartRate(data []byte) ([]byte, error) {
+	file, err := fit.DecodeBytes(data)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range file.Messages {
+		msg := &file.Messages[i]
+		for _, num := range heartRateFields[msg.Num] {
+			msg.RemoveField(num)
+		}
+	}
+
+	return fit.EncodeBytes(file)
+}
@@ -0,0 +1,109 @@
+package sync
+
+import (
+	"github.com/yqt/garmin-intl2cn/util"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	HistoryKindWorkout  = "workout"
+	HistoryKindSchedule = "schedule"
+	HistoryKindCourse   = "course"
+)
+
+// HistoryEntry maps an item of the source account to its copy on the target account.
+type HistoryEntry struct {
+	Kind     string    `json:"kind"`
+	SourceId int64     `json:"sourceId"`
+	TargetId int64     `json:"targetId"`
+	Name     string    `json:"name"`
+	SyncedAt time.Time `json:"syncedAt"`
+}
+
+type historyKey struct {
+	kind     string
+	sourceId int64
+}
+
+// History remembers what was already copied, so syncs of items without a
+// natural match such as a start time don't create duplicates.
+type History struct {
+	path string
+
+	mu      sync.Mutex
+	entries map[historyKey]*HistoryEntry
+	now     func() time.Time
+}
+
+func NewHistory(path string) (*History, error) {
+	h := &History{
+		path:    path,
+		entries: make(map[historyKey]*HistoryEntry),
+		now:     time.Now,
+	}
+
+	entries := make([]*HistoryEntry, 0)
+	if err := util.ReadJSONFile(path, &entries); err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		h.entries[historyKey{entry.Kind, entry.SourceId}] = entry
+	}
+	return h, nil
+}
+
+func (h *History) Lookup(kind string, sourceId int64) (HistoryEntry, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	entry, ok := h.entries[historyKey{kind, sourceId}]
+	if !ok {
+		return HistoryEntry{}, false
+	}
+	return *entry, true
+}
+
+func (h *History) Record(kind string, sourceId int64, targetId int64, name string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.entries[historyKey{kind, sourceId}] = &HistoryEntry{
+		Kind:     kind,
+		SourceId: sourceId,
+		TargetId: targetId,
+		Name:     name,
+		SyncedAt: h.now(),
+	}
+	return h.save()
+}
+
+func (h *History) Entries() []HistoryEntry {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	entries := make([]HistoryEntry, 0, len(h.entries))
+	for _, entry := range h.sortedEntries() {
+		entries = append(entries, *entry)
+	}
+	return entries
+}
+
+func (h *History) sortedEntries() []*HistoryEntry {
+	entries := make([]*HistoryEntry, 0, len(h.entries))
+	for _, entry := range h.entries {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Kind != entries[j].Kind {
+			return entries[i].Kind < entries[j].Kind
+		}
+		return entries[i].SourceId < entries[j].SourceId
+	})
+	return entries
+}
+
+func (h *History) save() error {
+	return util.WriteJSONFile(h.path, h.sortedEntries())
+}
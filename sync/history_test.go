@@ -0,0 +1,49 @@
+package sync
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestHistory_RecordAndReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "history")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "sync_history.json")
+
+	h, err := NewHistory(path)
+	assert.Nil(t, err)
+	now := time.Date(2021, 9, 8, 1, 46, 40, 0, time.UTC)
+	h.now = func() time.Time {
+		return now
+	}
+
+	_, ok := h.Lookup(HistoryKindWorkout, 1)
+	assert.False(t, ok)
+
+	assert.Nil(t, h.Record(HistoryKindWorkout, 2, 20, "tempo"))
+	assert.Nil(t, h.Record(HistoryKindWorkout, 1, 10, "5x1km"))
+	assert.Nil(t, h.Record(HistoryKindSchedule, 1, 100, "5x1km"))
+
+	entry, ok := h.Lookup(HistoryKindWorkout, 1)
+	assert.True(t, ok)
+	assert.Equal(t, HistoryEntry{Kind: HistoryKindWorkout, SourceId: 1, TargetId: 10, Name: "5x1km", SyncedAt: now}, entry)
+
+	reloaded, err := NewHistory(path)
+	assert.Nil(t, err)
+	assert.Equal(t, h.Entries(), reloaded.Entries())
+	entries := reloaded.Entries()
+	assert.Len(t, entries, 3)
+	assert.Equal(t, HistoryKindSchedule, entries[0].Kind)
+	assert.Equal(t, int64(1), entries[1].SourceId)
+	assert.Equal(t, int64(2), entries[2].SourceId)
+
+	// leftover temp files would mean saves are not atomic
+	files, err := ioutil.ReadDir(dir)
+	assert.Nil(t, err)
+	assert.Len(t, files, 1)
+}
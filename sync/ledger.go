@@ -0,0 +1,121 @@
+package sync
+
+import (
+	"github.com/yqt/garmin-intl2cn/util"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ImportEntry is a local file imported to one or more accounts.
+type ImportEntry struct {
+	Hash     string `json:"hash"`
+	FileName string `json:"fileName"`
+	// StartTime is only known for FIT files
+	StartTime  time.Time `json:"startTime"`
+	Accounts   []string  `json:"accounts"`
+	ImportedAt time.Time `json:"importedAt"`
+}
+
+// ImportLedger remembers the local files already imported, so renamed or copied
+// files are not uploaded twice.
+type ImportLedger struct {
+	path string
+
+	mu      sync.Mutex
+	entries map[string]*ImportEntry
+	now     func() time.Time
+}
+
+func NewImportLedger(path string) (*ImportLedger, error) {
+	l := &ImportLedger{
+		path:    path,
+		entries: make(map[string]*ImportEntry),
+		now:     time.Now,
+	}
+
+	entries := make([]*ImportEntry, 0)
+	if err := util.ReadJSONFile(path, &entries); err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		l.entries[entry.Hash] = entry
+	}
+	return l, nil
+}
+
+// Imported reports whether a file with the same hash, or a FIT file with the
+// same start time, was already imported to account.
+func (l *ImportLedger) Imported(hash string, startTime time.Time, account string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if entry, ok := l.entries[hash]; ok && containsAccount(entry.Accounts, account) {
+		return true
+	}
+	if startTime.IsZero() {
+		return false
+	}
+	for _, entry := range l.entries {
+		if entry.StartTime.Equal(startTime) && containsAccount(entry.Accounts, account) {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *ImportLedger) Record(hash string, fileName string, startTime time.Time, account string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entry, ok := l.entries[hash]
+	if !ok {
+		entry = &ImportEntry{
+			Hash:      hash,
+			FileName:  fileName,
+			StartTime: startTime,
+			Accounts:  make([]string, 0, 1),
+		}
+		l.entries[hash] = entry
+	}
+	if !containsAccount(entry.Accounts, account) {
+		entry.Accounts = append(entry.Accounts, account)
+	}
+	entry.ImportedAt = l.now()
+	return l.save()
+}
+
+func (l *ImportLedger) Entries() []ImportEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entries := make([]ImportEntry, 0, len(l.entries))
+	for _, entry := range l.sortedEntries() {
+		entries = append(entries, *entry)
+	}
+	return entries
+}
+
+func (l *ImportLedger) sortedEntries() []*ImportEntry {
+	entries := make([]*ImportEntry, 0, len(l.entries))
+	for _, entry := range l.entries {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Hash < entries[j].Hash
+	})
+	return entries
+}
+
+func (l *ImportLedger) save() error {
+	return util.WriteJSONFile(l.path, l.sortedEntries())
+}
+
+func containsAccount(accounts []string, account string) bool {
+	for _, a := range accounts {
+		if a == account {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,37 @@
+package sync
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestImportLedger(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ledger")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "import_ledger.json")
+
+	ledger, err := NewImportLedger(path)
+	assert.Nil(t, err)
+	startTime := time.Date(2021, 1, 2, 0, 30, 0, 0, time.UTC)
+	assert.False(t, ledger.Imported("hash1", startTime, AccountCn))
+
+	assert.Nil(t, ledger.Record("hash1", "a.fit", startTime, AccountCn))
+	assert.True(t, ledger.Imported("hash1", startTime, AccountCn))
+	assert.False(t, ledger.Imported("hash1", startTime, AccountIntl))
+	// the same activity exported again by another app
+	assert.True(t, ledger.Imported("hash2", startTime, AccountCn))
+	assert.False(t, ledger.Imported("hash2", time.Time{}, AccountCn))
+
+	assert.Nil(t, ledger.Record("hash1", "a.fit", startTime, AccountIntl))
+	ledger, err = NewImportLedger(path)
+	assert.Nil(t, err)
+	entries := ledger.Entries()
+	assert.Len(t, entries, 1)
+	assert.Equal(t, []string{AccountCn, AccountIntl}, entries[0].Accounts)
+	assert.True(t, entries[0].StartTime.Equal(startTime))
+}
@@ -0,0 +1,80 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"github.com/yqt/garmin-intl2cn/garmin"
+	"strings"
+)
+
+// Mirror copies the activities of source listed by filter to target, skipping
+// those target already has. Rules set by Filter, transformers set by Transformers
+// and gear linked by LinkGear apply as for syncs, up to Workers activities are
+// copied at the same time, and the outcome of each activity is recorded in the
+// queue set by Queue, if any.
+func Mirror(ctx context.Context, source Source, target Target, filter garmin.ActivityFilter, opts ...Option) (bool, string, error) {
+	o := newOptions(opts...)
+
+	items, err := source.List(ctx, filter)
+	if err != nil {
+		return false, "", err
+	}
+
+	succeedIds := make([]int64, 0)
+	failedIds := make([]int64, 0)
+	skippedIds := make([]int64, 0)
+	filteredActivities := make([]string, 0)
+	pending := make([]garmin.ActivityListItem, 0, len(items))
+	for _, item := range items {
+		if ok, reason := FilterActivity(o.rules, item); !ok {
+			filteredActivities = append(filteredActivities, fmt.Sprintf("%d: %s", item.ActivityId, reason))
+			retrySucceeded(o.retryQueue, item.ActivityId)
+			continue
+		}
+		exists, err := target.Exists(ctx, item)
+		if err != nil {
+			logrus.WithFields(logrus.Fields{
+				"activityId": item.ActivityId,
+				"err":        err,
+			}).Error("activity lookup on target failed")
+			failedIds = append(failedIds, item.ActivityId)
+			retryFailed(o.retryQueue, item.ActivityId, err)
+			continue
+		}
+		if exists {
+			skippedIds = append(skippedIds, item.ActivityId)
+			retrySucceeded(o.retryQueue, item.ActivityId)
+			continue
+		}
+		pending = append(pending, item)
+	}
+
+	for _, result := range transferActivities(ctx, source, target, pending, o) {
+		if errors.Is(result.Err, garmin.ErrDuplicateActivity) {
+			skippedIds = append(skippedIds, result.ActivityId)
+			retrySucceeded(o.retryQueue, result.ActivityId)
+			continue
+		}
+		if result.Err != nil {
+			failedIds = append(failedIds, result.ActivityId)
+			retryFailed(o.retryQueue, result.ActivityId, result.Err)
+			continue
+		}
+		succeedIds = append(succeedIds, result.ActivityId)
+		retrySucceeded(o.retryQueue, result.ActivityId)
+	}
+
+	suc := true
+	if len(succeedIds) == 0 && len(failedIds) != 0 {
+		suc = false
+	}
+	msg := fmt.Sprintf(
+		"id[%v] succeeded. id[%v] failed. id[%v] skipped.",
+		succeedIds, failedIds, skippedIds)
+	if len(filteredActivities) > 0 {
+		msg += fmt.Sprintf(" id[%s] filtered.", strings.Join(filteredActivities, "; "))
+	}
+	return suc, msg, nil
+}
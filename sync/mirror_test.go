@@ -0,0 +1,217 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/yqt/garmin-intl2cn/archive"
+	"github.com/yqt/garmin-intl2cn/garmin"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeSource struct {
+	activities []garmin.Activity
+	failing    map[int64]bool
+}
+
+func (s *fakeSource) List(ctx context.Context, filter garmin.ActivityFilter) ([]garmin.ActivityListItem, error) {
+	items := make([]garmin.ActivityListItem, 0)
+	for _, activity := range s.activities {
+		items = append(items, activity.ListItem())
+	}
+	return items, nil
+}
+
+func (s *fakeSource) Fetch(ctx context.Context, item garmin.ActivityListItem) (garmin.Activity, []garmin.ActivityFile, error) {
+	if s.failing[item.ActivityId] {
+		return garmin.Activity{}, nil, errors.New("download failed")
+	}
+	for _, activity := range s.activities {
+		if activity.ActivityId == item.ActivityId {
+			return activity, []garmin.ActivityFile{garmin.NewActivityFile("activity.fit", []byte("fit"))}, nil
+		}
+	}
+	return garmin.Activity{}, nil, errors.New("not found")
+}
+
+type fakeStore struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func (s *fakeStore) Exists(ctx context.Context, key string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.objects[key]
+	return ok, nil
+}
+
+func (s *fakeStore) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.objects[key] = data
+	return nil
+}
+
+// uncheckedStore counts the puts StorageTarget makes without a lookup.
+type uncheckedStore struct {
+	fakeStore
+	unchecked int
+}
+
+func (s *uncheckedStore) PutUnchecked(ctx context.Context, key string, data []byte, contentType string) error {
+	s.mu.Lock()
+	s.unchecked++
+	s.mu.Unlock()
+	return s.Put(ctx, key, data, contentType)
+}
+
+type fakeTarget struct {
+	mu         sync.Mutex
+	existsErr  map[int64]error
+	duplicates map[int64]bool
+	uploaded   []garmin.Activity
+}
+
+func (t *fakeTarget) Exists(ctx context.Context, item garmin.ActivityListItem) (bool, error) {
+	return false, t.existsErr[item.ActivityId]
+}
+
+func (t *fakeTarget) Upload(ctx context.Context, activity garmin.Activity, files []garmin.ActivityFile) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.duplicates[activity.ActivityId] {
+		return garmin.ErrDuplicateActivity
+	}
+	t.uploaded = append(t.uploaded, activity)
+	return nil
+}
+
+func mirrorTestActivity(id int64, name string, typeKey string) garmin.Activity {
+	return garmin.Activity{
+		ActivityId:   id,
+		ActivityName: name,
+		ActivityType: garmin.ActivityType{TypeKey: typeKey},
+		Summary: garmin.Summary{
+			StartTimeLocal: "2021-01-02T08:30:00.0",
+			StartTimeGMT:   "2021-01-02T00:30:00.0",
+		},
+	}
+}
+
+func TestMirror_StorageTarget(t *testing.T) {
+	source := &fakeSource{
+		activities: []garmin.Activity{
+			mirrorTestActivity(1, "Morning Run", "running"),
+			mirrorTestActivity(2, "Walk", "walking"),
+			mirrorTestActivity(3, "Ride", "cycling"),
+		},
+		failing: map[int64]bool{3: true},
+	}
+	store := &fakeStore{objects: make(map[string][]byte)}
+	target := NewStorageTarget(store)
+
+	rules, err := ParseRules([]string{"exclude type = walking"})
+	assert.Nil(t, err)
+	suc, msg, err := Mirror(context.Background(), source, target, garmin.ActivityFilter{}, Filter(rules...))
+	assert.Nil(t, err)
+	assert.True(t, suc)
+	assert.Equal(t, `id[[1]] succeeded. id[[3]] failed. id[[]] skipped. id[2: matched "exclude type = walking"] filtered.`, msg)
+	assert.Equal(t, []byte("fit"), store.objects["2021/01/1.fit"])
+	assert.Contains(t, string(store.objects["2021/01/1.json"]), `"activityName": "Morning Run"`)
+
+	source.failing = nil
+	_, msg, err = Mirror(context.Background(), source, target, garmin.ActivityFilter{}, Filter(rules...))
+	assert.Nil(t, err)
+	assert.Equal(t, `id[[3]] succeeded. id[[]] failed. id[[1]] skipped. id[2: matched "exclude type = walking"] filtered.`, msg)
+}
+
+func TestMirror_Archive(t *testing.T) {
+	root, err := ioutil.TempDir("", "mirror")
+	assert.Nil(t, err)
+	defer os.RemoveAll(root)
+	a, err := archive.Open(root)
+	assert.Nil(t, err)
+
+	source := &fakeSource{activities: []garmin.Activity{mirrorTestActivity(1, "Morning Run", "running")}}
+	suc, _, err := Mirror(context.Background(), source, NewArchiveTarget(a), garmin.ActivityFilter{})
+	assert.Nil(t, err)
+	assert.True(t, suc)
+	assert.True(t, a.Has(1))
+
+	// the archive is a source too
+	archiveSource := NewArchiveSource(a)
+	items, err := archiveSource.List(context.Background(), garmin.ActivityFilter{StartDate: time.Date(2021, 1, 2, 0, 0, 0, 0, time.UTC)})
+	assert.Nil(t, err)
+	assert.Len(t, items, 1)
+	items, err = archiveSource.List(context.Background(), garmin.ActivityFilter{StartDate: time.Date(2021, 1, 3, 0, 0, 0, 0, time.UTC)})
+	assert.Nil(t, err)
+	assert.Len(t, items, 0)
+
+	store := &fakeStore{objects: make(map[string][]byte)}
+	_, msg, err := Mirror(context.Background(), archiveSource, NewStorageTarget(store), garmin.ActivityFilter{})
+	assert.Nil(t, err)
+	assert.Equal(t, "id[[1]] succeeded. id[[]] failed. id[[]] skipped.", msg)
+	assert.Equal(t, []byte("fit"), store.objects["2021/01/1.fit"])
+}
+
+func TestStorageTarget_PutUnchecked(t *testing.T) {
+	source := &fakeSource{activities: []garmin.Activity{mirrorTestActivity(1, "Morning Run", "running")}}
+	store := &uncheckedStore{fakeStore: fakeStore{objects: make(map[string][]byte)}}
+
+	_, msg, err := Mirror(context.Background(), source, NewStorageTarget(store), garmin.ActivityFilter{})
+	assert.Nil(t, err)
+	assert.Equal(t, "id[[1]] succeeded. id[[]] failed. id[[]] skipped.", msg)
+	assert.Equal(t, 2, store.unchecked)
+	assert.Equal(t, []byte("fit"), store.objects["2021/01/1.fit"])
+}
+
+func TestMirror_QueueAndTransformers(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mirror")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	q, err := NewRetryQueue(filepath.Join(dir, "queue.json"), 3, time.Hour)
+	assert.Nil(t, err)
+
+	source := &fakeSource{activities: []garmin.Activity{
+		mirrorTestActivity(1, "Morning Run", "running"),
+		mirrorTestActivity(2, "Walk", "walking"),
+		mirrorTestActivity(3, "Ride", "cycling"),
+	}}
+	target := &fakeTarget{
+		existsErr:  map[int64]error{1: errors.New("parsing time")},
+		duplicates: map[int64]bool{2: true},
+	}
+	rename := TransformerFunc(func(file garmin.ActivityFile, activity garmin.Activity) (garmin.ActivityFile, garmin.Activity, error) {
+		activity.ActivityName += " (copy)"
+		return file, activity, nil
+	})
+
+	// one failing lookup does not abort the mirror
+	suc, msg, err := Mirror(context.Background(), source, target, garmin.ActivityFilter{}, Queue(q), Transformers(rename))
+	assert.Nil(t, err)
+	assert.True(t, suc)
+	assert.Equal(t, "id[[3]] succeeded. id[[1]] failed. id[[2]] skipped.", msg)
+	assert.Len(t, target.uploaded, 1)
+	assert.Equal(t, "Ride (copy)", target.uploaded[0].ActivityName)
+
+	entries := q.Entries()
+	assert.Len(t, entries, 1)
+	assert.Equal(t, int64(1), entries[0].ActivityId)
+}
+
+func TestParseStartTime(t *testing.T) {
+	listed, err := parseStartTime("2021-01-02 00:30:00")
+	assert.Nil(t, err)
+	detailed, err := parseStartTime("2021-01-02T00:30:00.0")
+	assert.Nil(t, err)
+	assert.True(t, listed.Equal(detailed))
+
+	_, err = parseStartTime("")
+	assert.NotNil(t, err)
+}
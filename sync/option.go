@@ -0,0 +1,94 @@
+package sync
+
+import "github.com/yqt/garmin-intl2cn/garmin"
+
+const (
+	DefaultWorkers = 2
+)
+
+type options struct {
+	workers       int
+	clientOptions []garmin.Option
+	retryQueue    *RetryQueue
+	transformers  []Transformer
+	rules         []Rule
+	history       *History
+	linkGear      bool
+	validateOwner bool
+}
+
+type Option func(o *options)
+
+// Workers bounds how many activities are downloaded from the source account
+// and uploaded to the target account at the same time.
+func Workers(workers int) Option {
+	return func(o *options) {
+		if workers > 0 {
+			o.workers = workers
+		}
+	}
+}
+
+// Queue records failed transfers in q and retries due entries on following syncs.
+func Queue(q *RetryQueue) Option {
+	return func(o *options) {
+		o.retryQueue = q
+	}
+}
+
+// Transformers are applied in order to every downloaded file before it is uploaded.
+func Transformers(transformers ...Transformer) Option {
+	return func(o *options) {
+		o.transformers = append(o.transformers, transformers...)
+	}
+}
+
+// Filter only syncs activities passing rules, see FilterActivity.
+func Filter(rules ...Rule) Option {
+	return func(o *options) {
+		o.rules = append(o.rules, rules...)
+	}
+}
+
+// SyncHistory records copied workouts, courses and gear in h, so following syncs skip them.
+func SyncHistory(h *History) Option {
+	return func(o *options) {
+		o.history = h
+	}
+}
+
+// LinkGear links the gear used in each synced activity to the matching gear of the
+// target account, creating it there if missing. The mapping is kept in the history
+// set by SyncHistory. Activities the target already had, or was still processing
+// after the upload, are logged and left without gear.
+func LinkGear() Option {
+	return func(o *options) {
+		o.linkGear = true
+	}
+}
+
+// ValidateSameOwner refuses to sync when the intl and CN accounts share neither a
+// registered device nor the full name of their profiles. Accounts without devices
+// or a full name on either side are let through with a warning.
+func ValidateSameOwner() Option {
+	return func(o *options) {
+		o.validateOwner = true
+	}
+}
+
+// ClientOptions are applied to both the international and the CN client.
+func ClientOptions(clientOptions ...garmin.Option) Option {
+	return func(o *options) {
+		o.clientOptions = append(o.clientOptions, clientOptions...)
+	}
+}
+
+func newOptions(opts ...Option) *options {
+	o := &options{
+		workers: DefaultWorkers,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
@@ -0,0 +1,89 @@
+package sync
+
+import (
+	"errors"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"github.com/yqt/garmin-intl2cn/garmin"
+	"strings"
+)
+
+var ErrDifferentOwner = errors.New("intl and cn accounts belong to different users")
+
+// checkSameOwner fails with ErrDifferentOwner when ValidateSameOwner is set and
+// the logged in accounts don't look like the same person's, see sameOwner. When
+// the accounts lack the devices or names to tell, it only logs a warning.
+func checkSameOwner(clientIntl *garmin.Client, clientCn *garmin.Client, o *options) error {
+	if !o.validateOwner {
+		return nil
+	}
+
+	intlProfile, err := clientIntl.Profile()
+	if err != nil {
+		return err
+	}
+	cnProfile, err := clientCn.Profile()
+	if err != nil {
+		return err
+	}
+	intlDevices, err := clientIntl.Devices()
+	if err != nil {
+		return err
+	}
+	cnDevices, err := clientCn.Devices()
+	if err != nil {
+		return err
+	}
+
+	same, conclusive, reason := sameOwner(intlProfile, intlDevices, cnProfile, cnDevices)
+	fields := logrus.Fields{
+		"intl":   intlProfile.DisplayName,
+		"cn":     cnProfile.DisplayName,
+		"same":   same,
+		"reason": reason,
+	}
+	if !same && !conclusive {
+		// NOTE: CN profiles often lack the full name, refusing would block most setups
+		logrus.WithFields(fields).Warn("account owner could not be validated, sync allowed")
+		return nil
+	}
+	logrus.WithFields(fields).Info("account owner validated")
+	if !same {
+		return fmt.Errorf("%w: %s", ErrDifferentOwner, reason)
+	}
+	return nil
+}
+
+// sameOwner reports whether two accounts belong to the same person: they share
+// a registered device, or have the same full name. Devices are usually registered
+// in a single region, so accounts without a shared device still match by name.
+// The result is only conclusive when both accounts have devices and a full name.
+func sameOwner(intlProfile garmin.Profile, intlDevices []garmin.Device, cnProfile garmin.Profile, cnDevices []garmin.Device) (bool, bool, string) {
+	units := make(map[int64]bool)
+	for _, device := range intlDevices {
+		if device.UnitId != 0 {
+			units[device.UnitId] = true
+		}
+	}
+	cnUnits := 0
+	for _, device := range cnDevices {
+		if device.UnitId == 0 {
+			continue
+		}
+		cnUnits++
+		if units[device.UnitId] {
+			return true, true, fmt.Sprintf("device %d registered to both", device.UnitId)
+		}
+	}
+
+	intlName := strings.TrimSpace(intlProfile.FullName)
+	cnName := strings.TrimSpace(cnProfile.FullName)
+	if intlName != "" && strings.EqualFold(intlName, cnName) {
+		return true, true, "same full name"
+	}
+	if len(units) == 0 || cnUnits == 0 || intlName == "" || cnName == "" {
+		return false, false, fmt.Sprintf("no device registered to both and full names %q and %q, "+
+			"not enough to tell", intlName, cnName)
+	}
+	return false, true, fmt.Sprintf("no device registered to both and full names %q and %q differ", intlName, cnName)
+}
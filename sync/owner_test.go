@@ -0,0 +1,43 @@
+package sync
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/yqt/garmin-intl2cn/garmin"
+	"testing"
+)
+
+func TestSameOwner(t *testing.T) {
+	intl := garmin.Profile{FullName: "Li Lei"}
+	cn := garmin.Profile{FullName: "li lei "}
+	watch := garmin.Device{UnitId: 3312345678}
+	scale := garmin.Device{UnitId: 3387654321}
+
+	same, conclusive, _ := sameOwner(intl, []garmin.Device{watch}, garmin.Profile{FullName: "Han Meimei"}, []garmin.Device{scale, watch})
+	assert.True(t, same)
+	assert.True(t, conclusive)
+
+	// devices registered in a single region each still match by name
+	same, _, reason := sameOwner(intl, []garmin.Device{watch}, cn, []garmin.Device{scale})
+	assert.True(t, same)
+	assert.Equal(t, "same full name", reason)
+
+	same, conclusive, _ = sameOwner(intl, []garmin.Device{watch}, garmin.Profile{FullName: "Han Meimei"}, []garmin.Device{scale})
+	assert.False(t, same)
+	assert.True(t, conclusive)
+
+	same, _, _ = sameOwner(intl, nil, cn, []garmin.Device{scale})
+	assert.True(t, same)
+
+	// without devices on one side or a name, there is not enough to tell
+	same, conclusive, _ = sameOwner(intl, nil, garmin.Profile{FullName: "Han Meimei"}, nil)
+	assert.False(t, same)
+	assert.False(t, conclusive)
+
+	same, conclusive, _ = sameOwner(intl, []garmin.Device{watch}, garmin.Profile{}, []garmin.Device{scale})
+	assert.False(t, same)
+	assert.False(t, conclusive)
+
+	same, conclusive, _ = sameOwner(garmin.Profile{}, nil, garmin.Profile{}, nil)
+	assert.False(t, same)
+	assert.False(t, conclusive)
+}
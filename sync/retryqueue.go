@@ -0,0 +1,181 @@
+package sync
+
+import (
+	"errors"
+	"github.com/yqt/garmin-intl2cn/util"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	RetryStatePending = "pending"
+	RetryStateDead    = "dead"
+)
+
+// maxRetryDelay caps the exponential delay of entries failing many times in a row.
+const maxRetryDelay = 7 * 24 * time.Hour
+
+var ErrRetryEntryNotFound = errors.New("retry entry not found")
+
+type RetryEntry struct {
+	ActivityId  int64     `json:"activityId"`
+	State       string    `json:"state"`
+	Attempts    int       `json:"attempts"`
+	NextAttempt time.Time `json:"nextAttempt"`
+	LastError   string    `json:"lastError"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// RetryQueue keeps failed transfers across syncs. An entry is retried with an
+// exponential delay until it succeeds or reaches maxAttempts, after which it is
+// dead-lettered until requeued manually.
+type RetryQueue struct {
+	path        string
+	maxAttempts int
+	baseDelay   time.Duration
+
+	mu      sync.Mutex
+	entries map[int64]*RetryEntry
+	now     func() time.Time
+}
+
+func NewRetryQueue(path string, maxAttempts int, baseDelay time.Duration) (*RetryQueue, error) {
+	q := &RetryQueue{
+		path:        path,
+		maxAttempts: maxAttempts,
+		baseDelay:   baseDelay,
+		entries:     make(map[int64]*RetryEntry),
+		now:         time.Now,
+	}
+
+	entries := make([]*RetryEntry, 0)
+	if err := util.ReadJSONFile(path, &entries); err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		q.entries[entry.ActivityId] = entry
+	}
+	return q, nil
+}
+
+// Due returns ids of pending entries whose next attempt time has come.
+func (q *RetryQueue) Due() []int64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := q.now()
+	ids := make([]int64, 0)
+	for _, entry := range q.sortedEntries() {
+		if entry.State == RetryStatePending && !entry.NextAttempt.After(now) {
+			ids = append(ids, entry.ActivityId)
+		}
+	}
+	return ids
+}
+
+// Deferred reports whether activityId is queued but not due yet, or dead, so that
+// syncs finding it again leave it to the queue.
+func (q *RetryQueue) Deferred(activityId int64) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	entry, ok := q.entries[activityId]
+	if !ok {
+		return false
+	}
+	return entry.State == RetryStateDead || entry.NextAttempt.After(q.now())
+}
+
+func (q *RetryQueue) Entries() []RetryEntry {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	entries := make([]RetryEntry, 0, len(q.entries))
+	for _, entry := range q.sortedEntries() {
+		entries = append(entries, *entry)
+	}
+	return entries
+}
+
+func (q *RetryQueue) Fail(activityId int64, cause error) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := q.now()
+	entry, ok := q.entries[activityId]
+	if !ok {
+		entry = &RetryEntry{
+			ActivityId: activityId,
+			State:      RetryStatePending,
+		}
+		q.entries[activityId] = entry
+	}
+	entry.Attempts++
+	entry.LastError = cause.Error()
+	entry.UpdatedAt = now
+	if q.maxAttempts > 0 && entry.Attempts >= q.maxAttempts {
+		entry.State = RetryStateDead
+	} else {
+		entry.NextAttempt = now.Add(q.delay(entry.Attempts))
+	}
+
+	return q.save()
+}
+
+func (q *RetryQueue) Succeed(activityId int64) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if _, ok := q.entries[activityId]; !ok {
+		return nil
+	}
+	delete(q.entries, activityId)
+
+	return q.save()
+}
+
+// Requeue moves an entry back to pending with a fresh attempt budget.
+func (q *RetryQueue) Requeue(activityId int64) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	entry, ok := q.entries[activityId]
+	if !ok {
+		return ErrRetryEntryNotFound
+	}
+	now := q.now()
+	entry.State = RetryStatePending
+	entry.Attempts = 0
+	entry.NextAttempt = now
+	entry.UpdatedAt = now
+
+	return q.save()
+}
+
+// delay doubles baseDelay for every attempt after the first, up to maxRetryDelay.
+func (q *RetryQueue) delay(attempts int) time.Duration {
+	delay := q.baseDelay
+	for i := 1; i < attempts && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	return delay
+}
+
+func (q *RetryQueue) sortedEntries() []*RetryEntry {
+	entries := make([]*RetryEntry, 0, len(q.entries))
+	for _, entry := range q.entries {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].ActivityId < entries[j].ActivityId
+	})
+	return entries
+}
+
+func (q *RetryQueue) save() error {
+	return util.WriteJSONFile(q.path, q.sortedEntries())
+}
@@ -0,0 +1,108 @@
+package sync
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestRetryQueue(t *testing.T, now *time.Time) (*RetryQueue, string) {
+	dir, err := ioutil.TempDir("", "retryqueue")
+	assert.Nil(t, err)
+	path := filepath.Join(dir, "retry_queue.json")
+
+	q, err := NewRetryQueue(path, 3, time.Hour)
+	assert.Nil(t, err)
+	q.now = func() time.Time {
+		return *now
+	}
+	return q, dir
+}
+
+func TestRetryQueue_FailAndDue(t *testing.T) {
+	now := time.Date(2021, 5, 1, 8, 0, 0, 0, time.UTC)
+	q, dir := newTestRetryQueue(t, &now)
+	defer os.RemoveAll(dir)
+
+	assert.Nil(t, q.Fail(1, errors.New("upload failed")))
+	assert.Empty(t, q.Due())
+
+	now = now.Add(time.Hour)
+	assert.Equal(t, []int64{1}, q.Due())
+
+	assert.Nil(t, q.Fail(1, errors.New("upload failed again")))
+	now = now.Add(time.Hour)
+	assert.Empty(t, q.Due())
+	now = now.Add(time.Hour)
+	assert.Equal(t, []int64{1}, q.Due())
+
+	entries := q.Entries()
+	assert.Len(t, entries, 1)
+	assert.Equal(t, 2, entries[0].Attempts)
+	assert.Equal(t, "upload failed again", entries[0].LastError)
+}
+
+func TestRetryQueue_DeadLetterAndRequeue(t *testing.T) {
+	now := time.Date(2021, 5, 1, 8, 0, 0, 0, time.UTC)
+	q, dir := newTestRetryQueue(t, &now)
+	defer os.RemoveAll(dir)
+
+	for i := 0; i < 3; i++ {
+		assert.Nil(t, q.Fail(2, errors.New("download failed")))
+	}
+	now = now.Add(24 * time.Hour)
+	assert.Empty(t, q.Due())
+	assert.Equal(t, RetryStateDead, q.Entries()[0].State)
+
+	assert.Nil(t, q.Requeue(2))
+	assert.Equal(t, []int64{2}, q.Due())
+	assert.Equal(t, ErrRetryEntryNotFound, q.Requeue(3))
+
+	assert.Nil(t, q.Succeed(2))
+	assert.Empty(t, q.Entries())
+}
+
+func TestRetryQueue_Persistence(t *testing.T) {
+	now := time.Date(2021, 5, 1, 8, 0, 0, 0, time.UTC)
+	q, dir := newTestRetryQueue(t, &now)
+	defer os.RemoveAll(dir)
+
+	assert.Nil(t, q.Fail(3, errors.New("invalid status code: 500")))
+	assert.Nil(t, q.Fail(4, errors.New("invalid status code: 429")))
+	assert.Nil(t, q.Succeed(4))
+
+	reloaded, err := NewRetryQueue(q.path, 3, time.Hour)
+	assert.Nil(t, err)
+	assert.Equal(t, q.Entries(), reloaded.Entries())
+}
+
+func TestRetryQueue_Deferred(t *testing.T) {
+	now := time.Date(2021, 5, 1, 8, 0, 0, 0, time.UTC)
+	q, dir := newTestRetryQueue(t, &now)
+	defer os.RemoveAll(dir)
+
+	assert.False(t, q.Deferred(5))
+	assert.Nil(t, q.Fail(5, errors.New("upload failed")))
+	assert.True(t, q.Deferred(5))
+	now = now.Add(time.Hour)
+	assert.False(t, q.Deferred(5))
+
+	for i := 0; i < 2; i++ {
+		assert.Nil(t, q.Fail(5, errors.New("upload failed")))
+	}
+	now = now.Add(24 * time.Hour)
+	assert.True(t, q.Deferred(5))
+}
+
+func TestRetryQueue_DelayCap(t *testing.T) {
+	q := &RetryQueue{baseDelay: time.Hour}
+
+	assert.Equal(t, time.Hour, q.delay(1))
+	assert.Equal(t, 4*time.Hour, q.delay(3))
+	assert.Equal(t, maxRetryDelay, q.delay(10))
+	assert.Equal(t, maxRetryDelay, q.delay(100))
+}
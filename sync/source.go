@@ -0,0 +1,197 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"github.com/sirupsen/logrus"
+	"github.com/yqt/garmin-intl2cn/garmin"
+	"sync"
+	"time"
+)
+
+// Source is where syncs and Mirror read activities from.
+type Source interface {
+	// List returns the activities matching filter, most recent first.
+	List(ctx context.Context, filter garmin.ActivityFilter) ([]garmin.ActivityListItem, error)
+	// Fetch returns the metadata and the original files of a listed activity.
+	Fetch(ctx context.Context, item garmin.ActivityListItem) (garmin.Activity, []garmin.ActivityFile, error)
+}
+
+// Target is where syncs and Mirror write activities to.
+type Target interface {
+	// Exists reports whether the target already has the activity.
+	Exists(ctx context.Context, item garmin.ActivityListItem) (bool, error)
+	// Upload stores the files of an activity. Targets may return
+	// garmin.ErrDuplicateActivity for activities they already had.
+	Upload(ctx context.Context, activity garmin.Activity, files []garmin.ActivityFile) error
+}
+
+// detailsUser is implemented by transformers and targets reading fields of the
+// activity which activity lists do not carry, see GarminSource.Fetch.
+type detailsUser interface {
+	needsDetails() bool
+}
+
+// namingTarget is implemented by targets which rename uploaded activities,
+// which transfers only ask for when a transformer changed the name.
+type namingTarget interface {
+	uploadNamed(ctx context.Context, activity garmin.Activity, files []garmin.ActivityFile, name string) error
+}
+
+// GarminSource reads activities from a garmin account.
+type GarminSource struct {
+	client *garmin.Client
+	// details is set by fetchDetails
+	details bool
+}
+
+func NewGarminSource(client *garmin.Client) *GarminSource {
+	return &GarminSource{client: client}
+}
+
+func (s *GarminSource) List(ctx context.Context, filter garmin.ActivityFilter) ([]garmin.ActivityListItem, error) {
+	if err := s.client.Auth(false); err != nil {
+		return nil, err
+	}
+	return s.client.Activities(ctx, filter).All()
+}
+
+// Fetch returns the metadata carried by item, and only gets the full details of
+// the activity for items which only hold an id, such as queued retries, or when
+// a transformer or the target needs them.
+func (s *GarminSource) Fetch(ctx context.Context, item garmin.ActivityListItem) (garmin.Activity, []garmin.ActivityFile, error) {
+	activity := item.Activity()
+	if s.details || item.StartTimeGMT == "" {
+		var err error
+		activity, err = s.client.GetActivity(item.ActivityId)
+		if err != nil {
+			return garmin.Activity{}, nil, err
+		}
+	}
+	files, err := s.client.DownloadActivity(item.ActivityId, garmin.FormatOriginal)
+	if err != nil {
+		return garmin.Activity{}, nil, err
+	}
+	return activity, files, nil
+}
+
+// fetchDetails makes source get the full details of activities when target or
+// one of transformers needs them.
+func fetchDetails(source Source, target Target, transformers []Transformer) {
+	garminSource, ok := source.(*GarminSource)
+	if !ok {
+		return
+	}
+	users := []interface{}{target}
+	for _, t := range transformers {
+		users = append(users, t)
+	}
+	for _, user := range users {
+		if u, ok := user.(detailsUser); ok && u.needsDetails() {
+			garminSource.details = true
+			return
+		}
+	}
+}
+
+// GarminTarget uploads activities to a garmin account. Uploaded activities keep the
+// name garmin gives them unless a transformer renamed the activity.
+type GarminTarget struct {
+	client *garmin.Client
+	// gear is set by linkGear
+	gear *gearMapping
+
+	mu sync.Mutex
+	// days caches the activities of the target listed by Exists, by day
+	days map[string][]garmin.ActivityListItem
+}
+
+func NewGarminTarget(client *garmin.Client) *GarminTarget {
+	return &GarminTarget{
+		client: client,
+		days:   make(map[string][]garmin.ActivityListItem),
+	}
+}
+
+// Exists matches item against the target activities started around the same day,
+// the way synchronize matches the activity lists of both accounts.
+func (t *GarminTarget) Exists(ctx context.Context, item garmin.ActivityListItem) (bool, error) {
+	startTime, err := parseStartTime(item.StartTimeGMT)
+	if err != nil {
+		return false, err
+	}
+	day := time.Date(startTime.Year(), startTime.Month(), startTime.Day(), 0, 0, 0, 0, time.UTC)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := day.Format("2006-01-02")
+	activities, ok := t.days[key]
+	if !ok {
+		if err = t.client.Auth(false); err != nil {
+			return false, err
+		}
+		// NOTE: a day either side, since each account cuts days in its own timezone
+		filter := garmin.ActivityFilter{StartDate: day.AddDate(0, 0, -1), EndDate: day.AddDate(0, 0, 1)}
+		activities, err = t.client.Activities(ctx, filter).All()
+		if err != nil {
+			return false, err
+		}
+		t.days[key] = activities
+	}
+	for _, activity := range activities {
+		if other, err := parseStartTime(activity.StartTimeGMT); err == nil && other.Equal(startTime) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (t *GarminTarget) Upload(ctx context.Context, activity garmin.Activity, files []garmin.ActivityFile) error {
+	return t.uploadNamed(ctx, activity, files, "")
+}
+
+// uploadNamed uploads the files of activity, and renames the created activity
+// after name if set.
+func (t *GarminTarget) uploadNamed(ctx context.Context, activity garmin.Activity, files []garmin.ActivityFile, name string) error {
+	if err := t.client.Auth(false); err != nil {
+		return err
+	}
+	uploadedId, err := uploadActivityFiles(t.client, files, name)
+	if t.gear == nil {
+		return err
+	}
+	if errors.Is(err, garmin.ErrDuplicateActivity) {
+		logrus.WithFields(logrus.Fields{
+			"activityId": activity.ActivityId,
+		}).Info("activity already on target account, gear link skipped")
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	if uploadedId == 0 {
+		logrus.WithFields(logrus.Fields{
+			"activityId": activity.ActivityId,
+		}).Warn("uploaded activity is still processing, gear link skipped")
+		return nil
+	}
+	// NOTE: missing gear does not fail the transfer, the activity itself is synced
+	if err = t.gear.link(activity.ActivityId, uploadedId); err != nil {
+		logrus.WithFields(logrus.Fields{
+			"activityId": activity.ActivityId,
+			"err":        err,
+		}).Error("activity gear link failed")
+	}
+	return nil
+}
+
+// parseStartTime reads the start times of both activity lists, "2006-01-02 15:04:05",
+// and activities, "2006-01-02T15:04:05.0".
+func parseStartTime(startTime string) (time.Time, error) {
+	t, err := time.Parse("2006-01-02 15:04:05", startTime)
+	if err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02T15:04:05", startTime)
+}
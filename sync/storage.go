@@ -0,0 +1,67 @@
+package sync
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/yqt/garmin-intl2cn/archive"
+	"github.com/yqt/garmin-intl2cn/garmin"
+	"strconv"
+)
+
+// ObjectStore is a remote store of files by key, such as WebDAV or S3. Keys use forward slashes.
+type ObjectStore interface {
+	Exists(ctx context.Context, key string) (bool, error)
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+}
+
+// uncheckedPutter is implemented by stores whose Put looks the key up first, such
+// as s3, to let StorageTarget skip that lookup.
+type uncheckedPutter interface {
+	PutUnchecked(ctx context.Context, key string, data []byte, contentType string) error
+}
+
+// StorageTarget writes activities to an ObjectStore, as year/month/id.ext for the
+// files, id_n.ext for multi-file activities, and year/month/id.json for the metadata.
+type StorageTarget struct {
+	store ObjectStore
+}
+
+func NewStorageTarget(store ObjectStore) *StorageTarget {
+	return &StorageTarget{store: store}
+}
+
+// NOTE: the stored metadata is the full details of the activity
+func (t *StorageTarget) needsDetails() bool {
+	return true
+}
+
+func (t *StorageTarget) Exists(ctx context.Context, item garmin.ActivityListItem) (bool, error) {
+	return t.store.Exists(ctx, archive.ActivityKey(item.ActivityId, item.StartTimeLocal)+".json")
+}
+
+// Upload writes the metadata last, so an interrupted upload is redone by the next mirror.
+// Mirror only uploads activities whose metadata Exists found missing, so stores
+// implementing uncheckedPutter write without looking the keys up again.
+func (t *StorageTarget) Upload(ctx context.Context, activity garmin.Activity, files []garmin.ActivityFile) error {
+	put := t.store.Put
+	if store, ok := t.store.(uncheckedPutter); ok {
+		put = store.PutUnchecked
+	}
+
+	key := archive.ActivityKey(activity.ActivityId, activity.Summary.StartTimeLocal)
+	for i, file := range files {
+		name := key
+		if len(files) > 1 {
+			name += "_" + strconv.Itoa(i+1)
+		}
+		if err := put(ctx, name+archive.FileExt(file), file.Data, file.ContentType); err != nil {
+			return err
+		}
+	}
+
+	metadata, err := json.MarshalIndent(activity, "", "  ")
+	if err != nil {
+		return err
+	}
+	return put(ctx, key+".json", metadata, "application/json")
+}
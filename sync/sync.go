@@ -1,9 +1,13 @@
 package sync
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"github.com/sirupsen/logrus"
 	"github.com/yqt/garmin-intl2cn/garmin"
+	"strings"
+	"time"
 )
 
 const (
@@ -21,15 +25,22 @@ type ActivityListWrapper struct {
 	Type         int
 }
 
-func SynchronizeLatestActivities(userInfo UserInfo) (bool, string, error) {
-	clientIntl := garmin.NewClient(
-		garmin.Credentials(userInfo.Intl.Email, userInfo.Intl.Password),
-		garmin.SetEnv(garmin.ApiServiceHost, garmin.SsoPrefix),
-	)
-	clientCn := garmin.NewClient(
-		garmin.Credentials(userInfo.Cn.Email, userInfo.Cn.Password),
-		garmin.SetEnv(garmin.ApiServiceHostCn, garmin.SsoPrefixCn),
-	)
+func SynchronizeLatestActivities(userInfo UserInfo, opts ...Option) (bool, string, error) {
+	return synchronize(userInfo, garmin.ActivityFilter{Limit: 5}, garmin.ActivityFilter{Limit: 10}, opts...)
+}
+
+// BackfillActivities syncs every activity started on or after the day of since,
+// instead of only the latest ones.
+func BackfillActivities(userInfo UserInfo, since time.Time, opts ...Option) (bool, string, error) {
+	// NOTE: the CN list starts a day earlier since each account cuts days in its own timezone
+	return synchronize(userInfo, garmin.ActivityFilter{StartDate: since}, garmin.ActivityFilter{StartDate: since.AddDate(0, 0, -1)}, opts...)
+}
+
+// synchronize uploads the activities listed by intlFilter which are missing
+// from the activities listed by cnFilter.
+func synchronize(userInfo UserInfo, intlFilter garmin.ActivityFilter, cnFilter garmin.ActivityFilter, opts ...Option) (bool, string, error) {
+	o := newOptions(opts...)
+	clientIntl, clientCn := newClients(userInfo, o)
 
 	errChan := make(chan error)
 	defer close(errChan)
@@ -37,8 +48,8 @@ func SynchronizeLatestActivities(userInfo UserInfo) (bool, string, error) {
 	actChan := make(chan ActivityListWrapper)
 	defer close(actChan)
 
-	go getActivityList(clientIntl, 0, 5, ActivityListWrapperTypeIntl, actChan, errChan)
-	go getActivityList(clientCn, 0, 10, ActivityListWrapperTypeCn, actChan, errChan)
+	go getActivityList(clientIntl, intlFilter, ActivityListWrapperTypeIntl, actChan, errChan)
+	go getActivityList(clientCn, cnFilter, ActivityListWrapperTypeCn, actChan, errChan)
 
 	var (
 		intlActivityList []garmin.ActivityListItem
@@ -73,12 +84,19 @@ func SynchronizeLatestActivities(userInfo UserInfo) (bool, string, error) {
 	if lastErr != nil {
 		return false, "", lastErr
 	}
+	if err := checkSameOwner(clientIntl, clientCn, o); err != nil {
+		return false, "", err
+	}
 
 	succeedActivityIds := make([]int64, 0)
 	failedActivityIds := make([]int64, 0)
 	skippedActivityIds := make([]int64, 0)
+	deferredActivityIds := make([]int64, 0)
 
+	windowActivityIds := make(map[int64]bool)
+	missingActivityList := make([]garmin.ActivityListItem, 0)
 	for _, intlAct := range intlActivityList {
+		windowActivityIds[intlAct.ActivityId] = true
 		found := false
 		for _, cnAct := range cnActivityList {
 			if intlAct.Equals(cnAct) {
@@ -87,53 +105,118 @@ func SynchronizeLatestActivities(userInfo UserInfo) (bool, string, error) {
 			}
 		}
 		if !found {
-			file, fileName, err := clientIntl.DownloadActivity(intlAct.ActivityId)
-			if err != nil {
-				logrus.WithFields(logrus.Fields{
-					"activityId": intlAct.ActivityId,
-					"err":        err,
-				}).Error("activity download failed")
-				failedActivityIds = append(failedActivityIds, intlAct.ActivityId)
+			// NOTE: failed activities still in the window wait for the queue like the others
+			if o.retryQueue != nil && o.retryQueue.Deferred(intlAct.ActivityId) {
+				deferredActivityIds = append(deferredActivityIds, intlAct.ActivityId)
 				continue
 			}
-			err = clientCn.UploadActivity(fileName, file)
-			if err != nil {
+			missingActivityList = append(missingActivityList, intlAct)
+		} else {
+			skippedActivityIds = append(skippedActivityIds, intlAct.ActivityId)
+			retrySucceeded(o.retryQueue, intlAct.ActivityId)
+		}
+	}
+
+	if o.retryQueue != nil {
+		for _, id := range o.retryQueue.Due() {
+			if !windowActivityIds[id] {
+				missingActivityList = append(missingActivityList, garmin.ActivityListItem{ActivityId: id})
+			}
+		}
+	}
+
+	filteredActivities := make([]string, 0)
+	if len(o.rules) > 0 {
+		passedActivityList := make([]garmin.ActivityListItem, 0, len(missingActivityList))
+		for _, act := range missingActivityList {
+			// NOTE: queued retries outside the window only carry their id
+			if act.StartTimeGMT == "" {
+				activity, err := clientIntl.GetActivity(act.ActivityId)
+				if err != nil {
+					logrus.WithFields(logrus.Fields{
+						"activityId": act.ActivityId,
+						"err":        err,
+					}).Error("get activity failed")
+					failedActivityIds = append(failedActivityIds, act.ActivityId)
+					retryFailed(o.retryQueue, act.ActivityId, err)
+					continue
+				}
+				act = activity.ListItem()
+			}
+			if ok, reason := FilterActivity(o.rules, act); !ok {
 				logrus.WithFields(logrus.Fields{
-					"activityId": intlAct.ActivityId,
-					"err":        err,
-				}).Error("activity upload failed")
-				failedActivityIds = append(failedActivityIds, intlAct.ActivityId)
+					"activityId": act.ActivityId,
+					"reason":     reason,
+				}).Info("activity filtered")
+				filteredActivities = append(filteredActivities, fmt.Sprintf("%d: %s", act.ActivityId, reason))
+				retrySucceeded(o.retryQueue, act.ActivityId)
 				continue
 			}
-			succeedActivityIds = append(succeedActivityIds, intlAct.ActivityId)
-		} else {
-			skippedActivityIds = append(skippedActivityIds, intlAct.ActivityId)
+			passedActivityList = append(passedActivityList, act)
+		}
+		missingActivityList = passedActivityList
+	}
+
+	results := transferActivities(context.Background(), NewGarminSource(clientIntl), NewGarminTarget(clientCn), missingActivityList, o)
+	for _, result := range results {
+		if errors.Is(result.Err, garmin.ErrDuplicateActivity) {
+			skippedActivityIds = append(skippedActivityIds, result.ActivityId)
+			retrySucceeded(o.retryQueue, result.ActivityId)
+			continue
+		}
+		if result.Err != nil {
+			failedActivityIds = append(failedActivityIds, result.ActivityId)
+			retryFailed(o.retryQueue, result.ActivityId, result.Err)
+			continue
 		}
+		succeedActivityIds = append(succeedActivityIds, result.ActivityId)
+		retrySucceeded(o.retryQueue, result.ActivityId)
 	}
 
 	logrus.WithFields(logrus.Fields{
-		"succeedActivityIds": succeedActivityIds,
-		"failedActivityIds":  failedActivityIds,
-		"skippedActivityIds": skippedActivityIds,
-		"err":                err,
+		"succeedActivityIds":  succeedActivityIds,
+		"failedActivityIds":   failedActivityIds,
+		"skippedActivityIds":  skippedActivityIds,
+		"deferredActivityIds": deferredActivityIds,
+		"filteredActivities":  filteredActivities,
+		"err":                 err,
 	}).Debug("sync detail")
 
 	suc := true
 	if len(succeedActivityIds) == 0 && len(failedActivityIds) != 0 {
 		suc = false
 	}
-	return suc, fmt.Sprintf(
+	msg := fmt.Sprintf(
 		"id[%v] succeeded. id[%v] failed. id[%v] skipped.",
-		succeedActivityIds, failedActivityIds, skippedActivityIds), nil
+		succeedActivityIds, failedActivityIds, skippedActivityIds)
+	if len(deferredActivityIds) > 0 {
+		msg += fmt.Sprintf(" id[%v] deferred.", deferredActivityIds)
+	}
+	if len(filteredActivities) > 0 {
+		msg += fmt.Sprintf(" id[%s] filtered.", strings.Join(filteredActivities, "; "))
+	}
+	return suc, msg, nil
 }
 
-func getActivityList(client *garmin.Client, start int64, limit int64, actType int, resultChan chan<- ActivityListWrapper, errChan chan<- error) {
+func newClients(userInfo UserInfo, o *options) (*garmin.Client, *garmin.Client) {
+	clientIntl := garmin.NewClient(append([]garmin.Option{
+		garmin.Credentials(userInfo.Intl.Email, userInfo.Intl.Password),
+		garmin.SetEnv(garmin.ApiServiceHost, garmin.SsoPrefix),
+	}, o.clientOptions...)...)
+	clientCn := garmin.NewClient(append([]garmin.Option{
+		garmin.Credentials(userInfo.Cn.Email, userInfo.Cn.Password),
+		garmin.SetEnv(garmin.ApiServiceHostCn, garmin.SsoPrefixCn),
+	}, o.clientOptions...)...)
+	return clientIntl, clientCn
+}
+
+func getActivityList(client *garmin.Client, filter garmin.ActivityFilter, actType int, resultChan chan<- ActivityListWrapper, errChan chan<- error) {
 	err := client.Auth(false)
 	if err != nil {
 		errChan <- err
 		return
 	}
-	activityList, err := client.GetActivityList(start, limit)
+	activityList, err := client.Activities(context.Background(), filter).All()
 	if err != nil {
 		errChan <- err
 		return
@@ -144,3 +227,27 @@ func getActivityList(client *garmin.Client, start int64, limit int64, actType in
 	}
 	resultChan <- activityListWrapper
 }
+
+func retrySucceeded(q *RetryQueue, activityId int64) {
+	if q == nil {
+		return
+	}
+	if err := q.Succeed(activityId); err != nil {
+		logrus.WithFields(logrus.Fields{
+			"activityId": activityId,
+			"err":        err,
+		}).Error("retry queue update failed")
+	}
+}
+
+func retryFailed(q *RetryQueue, activityId int64, cause error) {
+	if q == nil {
+		return
+	}
+	if err := q.Fail(activityId, cause); err != nil {
+		logrus.WithFields(logrus.Fields{
+			"activityId": activityId,
+			"err":        err,
+		}).Error("retry queue update failed")
+	}
+}
@@ -0,0 +1,201 @@
+package sync
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/yqt/garmin-intl2cn/coord"
+	"github.com/yqt/garmin-intl2cn/garmin"
+	"github.com/yqt/garmin-intl2cn/privacy"
+	"text/template"
+)
+
+// Transformer rewrites an activity file, and the metadata describing it, between
+// downloading it from the source account and uploading it to the target account.
+// Files in a format a transformer does not handle are returned unchanged.
+type Transformer interface {
+	Transform(file garmin.ActivityFile, activity garmin.Activity) (garmin.ActivityFile, garmin.Activity, error)
+}
+
+type TransformerFunc func(file garmin.ActivityFile, activity garmin.Activity) (garmin.ActivityFile, garmin.Activity, error)
+
+func (f TransformerFunc) Transform(file garmin.ActivityFile, activity garmin.Activity) (garmin.ActivityFile, garmin.Activity, error) {
+	return f(file, activity)
+}
+
+// Chain applies transformers in order, feeding the output of each one into the next.
+func Chain(transformers ...Transformer) Transformer {
+	return TransformerFunc(func(file garmin.ActivityFile, activity garmin.Activity) (garmin.ActivityFile, garmin.Activity, error) {
+		var err error
+		for _, t := range transformers {
+			file, activity, err = t.Transform(file, activity)
+			if err != nil {
+				return file, activity, err
+			}
+		}
+		return file, activity, nil
+	})
+}
+
+const (
+	TransformerPrivacy         = "privacy"
+	TransformerCoordinates     = "coordinates"
+	TransformerRemoveHeartRate = "remove-heart-rate"
+	TransformerRename          = "rename"
+)
+
+// TransformerSettings holds the parameters of the transformers built by NewTransformers.
+type TransformerSettings struct {
+	PrivacyZones []privacy.Zone
+	PrivacyMode  privacy.Mode
+	// Conversion is the name of a coordinate conversion, see CoordinateConversionByName
+	Conversion   string
+	NameTemplate string
+}
+
+// NewTransformers builds the transformers listed by name, e.g. from config.
+// Transformers whose settings are empty are left out.
+// PrivacyZones are WGS-84 and converted to the datum of the activity, which is
+// GCJ-02 for a gcj02-to-wgs84 source and for FIT files converted to GCJ-02 by a
+// previous coordinates transformer.
+func NewTransformers(names []string, settings TransformerSettings) ([]Transformer, error) {
+	conversion, err := CoordinateConversionByName(settings.Conversion)
+	if err != nil {
+		return nil, err
+	}
+	sourceGCJ02 := settings.Conversion == ConversionGCJ02ToWGS84
+	convertedGCJ02 := sourceGCJ02
+
+	transformers := make([]Transformer, 0, len(names))
+	for _, name := range names {
+		var t Transformer
+		switch name {
+		case TransformerPrivacy:
+			if len(settings.PrivacyZones) > 0 {
+				t = privacyTransformer(zonesInDatum(settings.PrivacyZones, sourceGCJ02),
+					zonesInDatum(settings.PrivacyZones, convertedGCJ02), settings.PrivacyMode)
+			}
+		case TransformerCoordinates:
+			if conversion != nil {
+				t = CoordinateTransformer(conversion)
+				convertedGCJ02 = settings.Conversion == ConversionWGS84ToGCJ02
+			}
+		case TransformerRemoveHeartRate:
+			t = RemoveHeartRateTransformer()
+		case TransformerRename:
+			if settings.NameTemplate != "" {
+				t, err = RenameTransformer(settings.NameTemplate)
+				if err != nil {
+					return nil, err
+				}
+			}
+		default:
+			return nil, fmt.Errorf("unknown transformer: %q", name)
+		}
+		if t != nil {
+			transformers = append(transformers, t)
+		}
+	}
+	return transformers, nil
+}
+
+// PrivacyTransformer hides GPS points recorded inside zones, and clears the start
+// position of the activity if it lies in one of them. Zones have to be in the
+// datum of the activity, see NewTransformers.
+func PrivacyTransformer(zones []privacy.Zone, mode privacy.Mode) Transformer {
+	return privacyTransformer(zones, zones, mode)
+}
+
+// privacyTransformer checks FIT files against convertedZones, since a previous
+// CoordinateTransformer may have moved them to another datum than the metadata,
+// GPX and TCX files.
+func privacyTransformer(zones []privacy.Zone, convertedZones []privacy.Zone, mode privacy.Mode) Transformer {
+	return TransformerFunc(func(file garmin.ActivityFile, activity garmin.Activity) (garmin.ActivityFile, garmin.Activity, error) {
+		var err error
+		switch file.Format {
+		case garmin.FormatFIT:
+			file.Data, err = privacy.FIT(file.Data, convertedZones, mode)
+		case garmin.FormatGPX:
+			file.Data, err = privacy.GPX(file.Data, zones, mode)
+		case garmin.FormatTCX:
+			file.Data, err = privacy.TCX(file.Data, zones, mode)
+		}
+		summary := &activity.Summary
+		for _, zone := range zones {
+			if zone.Contains(summary.StartLatitude, summary.StartLongitude) {
+				summary.StartLatitude, summary.StartLongitude = 0, 0
+				break
+			}
+		}
+		return file, activity, err
+	})
+}
+
+func zonesInDatum(zones []privacy.Zone, gcj02 bool) []privacy.Zone {
+	if !gcj02 {
+		return zones
+	}
+	converted := make([]privacy.Zone, len(zones))
+	for i, zone := range zones {
+		zone.Latitude, zone.Longitude = coord.WGS84ToGCJ02(zone.Latitude, zone.Longitude)
+		converted[i] = zone
+	}
+	return converted
+}
+
+// CoordinateTransformer rewrites the positions of FIT files with conversion.
+func CoordinateTransformer(conversion CoordinateConversion) Transformer {
+	return TransformerFunc(func(file garmin.ActivityFile, activity garmin.Activity) (garmin.ActivityFile, garmin.Activity, error) {
+		if file.Format != garmin.FormatFIT {
+			return file, activity, nil
+		}
+		var err error
+		file.Data, err = convertFITCoordinates(file.Data, conversion)
+		return file, activity, err
+	})
+}
+
+// RemoveHeartRateTransformer drops heart rate samples and summaries from FIT, TCX and GPX files.
+func RemoveHeartRateTransformer() Transformer {
+	return TransformerFunc(func(file garmin.ActivityFile, activity garmin.Activity) (garmin.ActivityFile, garmin.Activity, error) {
+		var err error
+		switch file.Format {
+		case garmin.FormatFIT:
+			file.Data, err = removeFITHeartRate(file.Data)
+		case garmin.FormatTCX:
+			file.Data = tcxHeartRatePattern.ReplaceAll(file.Data, nil)
+		case garmin.FormatGPX:
+			file.Data = gpxHeartRatePattern.ReplaceAll(file.Data, nil)
+		}
+		activity.Summary.AverageHR = 0
+		activity.Summary.MaxHR = 0
+		return file, activity, err
+	})
+}
+
+// RenameTransformer sets the activity name from a text/template executed on
+// the activity, e.g. `{{.ActivityName}} ({{.ActivityType.TypeKey}})`.
+func RenameTransformer(nameTemplate string) (Transformer, error) {
+	tmpl, err := template.New("name").Parse(nameTemplate)
+	if err != nil {
+		return nil, err
+	}
+	return renameTransformer{tmpl: tmpl}, nil
+}
+
+type renameTransformer struct {
+	tmpl *template.Template
+}
+
+func (t renameTransformer) Transform(file garmin.ActivityFile, activity garmin.Activity) (garmin.ActivityFile, garmin.Activity, error) {
+	buf := &bytes.Buffer{}
+	if err := t.tmpl.Execute(buf, activity); err != nil {
+		return file, activity, err
+	}
+	activity.ActivityName = buf.String()
+	return file, activity, nil
+}
+
+// NOTE: templates may use any field of the activity, not only those of activity lists
+func (t renameTransformer) needsDetails() bool {
+	return true
+}
@@ -0,0 +1,195 @@
+package sync
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/yqt/garmin-intl2cn/coord"
+	"github.com/yqt/garmin-intl2cn/fit"
+	"github.com/yqt/garmin-intl2cn/garmin"
+	"github.com/yqt/garmin-intl2cn/privacy"
+	"io/ioutil"
+	"testing"
+)
+
+func readFITFixture(t *testing.T) garmin.ActivityFile {
+	data, err := ioutil.ReadFile("../fit/testdata/activity.fit")
+	assert.Nil(t, err)
+	return garmin.NewActivityFile("activity.fit", data)
+}
+
+func TestChain_Order(t *testing.T) {
+	appendName := func(suffix string) Transformer {
+		return TransformerFunc(func(file garmin.ActivityFile, activity garmin.Activity) (garmin.ActivityFile, garmin.Activity, error) {
+			activity.ActivityName += suffix
+			return file, activity, nil
+		})
+	}
+
+	_, activity, err := Chain(appendName(" a"), appendName(" b")).Transform(garmin.ActivityFile{}, garmin.Activity{ActivityName: "run"})
+	assert.Nil(t, err)
+	assert.Equal(t, "run a b", activity.ActivityName)
+}
+
+func TestRenameTransformer(t *testing.T) {
+	rename, err := RenameTransformer("{{.ActivityName}} ({{.ActivityType.TypeKey}})")
+	assert.Nil(t, err)
+
+	activity := garmin.Activity{
+		ActivityName: "Shanghai Running",
+		ActivityType: garmin.ActivityType{TypeKey: "running"},
+	}
+	_, activity, err = rename.Transform(garmin.ActivityFile{}, activity)
+	assert.Nil(t, err)
+	assert.Equal(t, "Shanghai Running (running)", activity.ActivityName)
+
+	_, err = RenameTransformer("{{.ActivityName")
+	assert.NotNil(t, err)
+}
+
+func TestRemoveHeartRateTransformer_FIT(t *testing.T) {
+	file, activity, err := RemoveHeartRateTransformer().Transform(readFITFixture(t), garmin.Activity{
+		Summary: garmin.Summary{AverageHR: 122, MaxHR: 124},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 0.0, activity.Summary.AverageHR)
+	assert.Equal(t, 0.0, activity.Summary.MaxHR)
+
+	decoded, err := fit.DecodeBytes(file.Data)
+	assert.Nil(t, err)
+	records := decoded.Records()
+	assert.Len(t, records, 5)
+	for _, record := range records {
+		assert.Equal(t, uint8(0), record.HeartRate)
+		assert.True(t, record.HasPosition)
+	}
+	assert.Equal(t, uint8(0), decoded.Sessions()[0].MaxHeartRate)
+}
+
+func TestRemoveHeartRateTransformer_XML(t *testing.T) {
+	tcx := garmin.NewActivityFile("activity.tcx", []byte(`<Lap>
+  <AverageHeartRateBpm><Value>120</Value></AverageHeartRateBpm>
+  <Trackpoint>
+    <HeartRateBpm>
+      <Value>121</Value>
+    </HeartRateBpm>
+    <Cadence>80</Cadence>
+  </Trackpoint>
+</Lap>`))
+	file, _, err := RemoveHeartRateTransformer().Transform(tcx, garmin.Activity{})
+	assert.Nil(t, err)
+	assert.Equal(t, `<Lap>
+  <Trackpoint>
+    <Cadence>80</Cadence>
+  </Trackpoint>
+</Lap>`, string(file.Data))
+
+	gpx := garmin.NewActivityFile("activity.gpx", []byte(`<trkpt lat="31.23" lon="121.47"><extensions><gpxtpx:TrackPointExtension><gpxtpx:hr>121</gpxtpx:hr><gpxtpx:cad>80</gpxtpx:cad></gpxtpx:TrackPointExtension></extensions></trkpt>`))
+	file, _, err = RemoveHeartRateTransformer().Transform(gpx, garmin.Activity{})
+	assert.Nil(t, err)
+	assert.Equal(t, `<trkpt lat="31.23" lon="121.47"><extensions><gpxtpx:TrackPointExtension><gpxtpx:cad>80</gpxtpx:cad></gpxtpx:TrackPointExtension></extensions></trkpt>`, string(file.Data))
+}
+
+func TestCoordinateTransformer_SkipsOtherFormats(t *testing.T) {
+	gpx := garmin.NewActivityFile("activity.gpx", []byte(`<trkpt lat="31.23" lon="121.47"></trkpt>`))
+	file, _, err := CoordinateTransformer(coord.WGS84ToGCJ02).Transform(gpx, garmin.Activity{})
+	assert.Nil(t, err)
+	assert.Equal(t, gpx, file)
+}
+
+func TestNewTransformers(t *testing.T) {
+	transformers, err := NewTransformers([]string{TransformerPrivacy, TransformerCoordinates, TransformerRename}, TransformerSettings{})
+	assert.Nil(t, err)
+	assert.Len(t, transformers, 0)
+
+	transformers, err = NewTransformers([]string{TransformerPrivacy, TransformerCoordinates, TransformerRemoveHeartRate}, TransformerSettings{
+		PrivacyZones: []privacy.Zone{{Latitude: 31.2304, Longitude: 121.4737, Radius: 30}},
+		PrivacyMode:  privacy.ModeTruncate,
+		Conversion:   ConversionWGS84ToGCJ02,
+	})
+	assert.Nil(t, err)
+	assert.Len(t, transformers, 3)
+
+	file, _, err := Chain(transformers...).Transform(readFITFixture(t), garmin.Activity{})
+	assert.Nil(t, err)
+	decoded, err := fit.DecodeBytes(file.Data)
+	assert.Nil(t, err)
+	records := decoded.Records()
+	assert.True(t, len(records) < 5)
+	for _, record := range records {
+		assert.Equal(t, uint8(0), record.HeartRate)
+		// positions are converted only after the zone was checked in WGS-84
+		lat, lng := coord.WGS84ToGCJ02(31.2304, 121.4737)
+		assert.True(t, privacy.Distance(lat, lng, record.Position.Lat, record.Position.Long) > 30)
+	}
+
+	_, err = NewTransformers([]string{"unknown"}, TransformerSettings{})
+	assert.NotNil(t, err)
+	_, err = NewTransformers([]string{TransformerCoordinates}, TransformerSettings{Conversion: "wgs84-to-gcj2"})
+	assert.NotNil(t, err)
+}
+
+func TestNewTransformers_GCJ02Source(t *testing.T) {
+	zones := []privacy.Zone{{Latitude: 31.2304, Longitude: 121.4737, Radius: 30}}
+	wgs84 := readFITFixture(t)
+	original, err := fit.DecodeBytes(wgs84.Data)
+	assert.Nil(t, err)
+
+	// a CN source records its tracks in GCJ-02
+	gcj02 := wgs84
+	gcj02.Data, err = convertFITCoordinates(wgs84.Data, coord.WGS84ToGCJ02)
+	assert.Nil(t, err)
+	startLat, startLng := coord.WGS84ToGCJ02(31.2304, 121.4737)
+	activity := garmin.Activity{Summary: garmin.Summary{StartLatitude: startLat, StartLongitude: startLng}}
+
+	for _, names := range [][]string{
+		{TransformerPrivacy, TransformerCoordinates},
+		{TransformerCoordinates, TransformerPrivacy},
+	} {
+		transformers, err := NewTransformers(names, TransformerSettings{
+			PrivacyZones: zones,
+			PrivacyMode:  privacy.ModeTruncate,
+			Conversion:   ConversionGCJ02ToWGS84,
+		})
+		assert.Nil(t, err)
+
+		file, transformed, err := Chain(transformers...).Transform(gcj02, activity)
+		assert.Nil(t, err)
+		assert.Equal(t, 0.0, transformed.Summary.StartLatitude)
+		assert.Equal(t, 0.0, transformed.Summary.StartLongitude)
+
+		decoded, err := fit.DecodeBytes(file.Data)
+		assert.Nil(t, err)
+		assert.True(t, len(decoded.Records()) < len(original.Records()), names)
+		for _, record := range decoded.Records() {
+			assert.True(t, privacy.Distance(31.2304, 121.4737, record.Position.Lat, record.Position.Long) > 30)
+		}
+	}
+}
+
+func TestPrivacyTransformer_Metadata(t *testing.T) {
+	zones := []privacy.Zone{{Latitude: 31.2304, Longitude: 121.4737, Radius: 30}}
+	activity := garmin.Activity{Summary: garmin.Summary{StartLatitude: 31.3, StartLongitude: 121.5}}
+
+	_, transformed, err := PrivacyTransformer(zones, privacy.ModeStrip).Transform(garmin.ActivityFile{}, activity)
+	assert.Nil(t, err)
+	assert.Equal(t, activity, transformed)
+
+	activity.Summary.StartLatitude, activity.Summary.StartLongitude = 31.2305, 121.4737
+	_, transformed, err = PrivacyTransformer(zones, privacy.ModeStrip).Transform(garmin.ActivityFile{}, activity)
+	assert.Nil(t, err)
+	assert.Equal(t, 0.0, transformed.Summary.StartLatitude)
+	assert.Equal(t, 0.0, transformed.Summary.StartLongitude)
+}
+
+func TestTransformActivity_MultiFile(t *testing.T) {
+	rename, err := RenameTransformer("{{.ActivityName}} (intl)")
+	assert.Nil(t, err)
+	files := []garmin.ActivityFile{
+		garmin.NewActivityFile("1_ACTIVITY.fit", []byte("a")),
+		garmin.NewActivityFile("1_ACTIVITY_1.fit", []byte("b")),
+	}
+
+	activity, transformed, err := transformActivity(rename, garmin.Activity{ActivityName: "Run"}, files)
+	assert.Nil(t, err)
+	assert.Equal(t, "Run (intl)", activity.ActivityName)
+	assert.Equal(t, files, transformed)
+}
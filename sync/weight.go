@@ -0,0 +1,82 @@
+package sync
+
+import (
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"github.com/yqt/garmin-intl2cn/garmin"
+	"time"
+)
+
+// weightMatchWindow is how far apart two entries may be measured to count as the same one.
+const weightMatchWindow = time.Minute
+
+// SynchronizeWeights copies the weight and body composition entries measured
+// since the day of since that the CN account does not have yet.
+func SynchronizeWeights(userInfo UserInfo, since time.Time, opts ...Option) (bool, string, error) {
+	o := newOptions(opts...)
+	clientIntl, clientCn := newClients(userInfo, o)
+
+	if err := clientIntl.Auth(false); err != nil {
+		return false, "", err
+	}
+	if err := clientCn.Auth(false); err != nil {
+		return false, "", err
+	}
+	if err := checkSameOwner(clientIntl, clientCn, o); err != nil {
+		return false, "", err
+	}
+
+	now := time.Now()
+	intlEntries, err := clientIntl.GetWeightEntries(since, now)
+	if err != nil {
+		return false, "", err
+	}
+	// NOTE: widen the CN range since each account cuts days in its own timezone
+	cnEntries, err := clientCn.GetWeightEntries(since.AddDate(0, 0, -1), now.AddDate(0, 0, 1))
+	if err != nil {
+		return false, "", err
+	}
+
+	missing := missingWeightEntries(intlEntries, cnEntries)
+	logrus.WithFields(logrus.Fields{
+		"intlEntries":    len(intlEntries),
+		"cnEntries":      len(cnEntries),
+		"missingEntries": len(missing),
+	}).Debug("weight sync detail")
+	if len(missing) == 0 {
+		return true, fmt.Sprintf("%d weight entries skipped.", len(intlEntries)), nil
+	}
+
+	if err := clientCn.UploadWeightEntries(missing); err != nil {
+		return false, "", err
+	}
+
+	dates := make([]string, 0, len(missing))
+	for _, entry := range missing {
+		dates = append(dates, entry.CalendarDate)
+	}
+	return true, fmt.Sprintf(
+		"weight entries of %v succeeded. %d weight entries skipped.",
+		dates, len(intlEntries)-len(missing)), nil
+}
+
+func missingWeightEntries(source []garmin.WeightEntry, target []garmin.WeightEntry) []garmin.WeightEntry {
+	missing := make([]garmin.WeightEntry, 0)
+	for _, entry := range source {
+		found := false
+		for _, targetEntry := range target {
+			d := entry.Time().Sub(targetEntry.Time())
+			if d < 0 {
+				d = -d
+			}
+			if d <= weightMatchWindow {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, entry)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,24 @@
+package sync
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/yqt/garmin-intl2cn/garmin"
+	"testing"
+)
+
+func TestMissingWeightEntries(t *testing.T) {
+	source := []garmin.WeightEntry{
+		{CalendarDate: "2021-09-08", TimestampGMT: 1631065600000},
+		{CalendarDate: "2021-09-09", TimestampGMT: 1631152000000},
+		{CalendarDate: "2021-09-10", TimestampGMT: 1631238400000},
+	}
+	target := []garmin.WeightEntry{
+		// re-uploaded entries may be rounded to the second or minute
+		{CalendarDate: "2021-09-08", TimestampGMT: 1631065630000},
+		{CalendarDate: "2021-09-09", TimestampGMT: 1631152000000 - 2*60*1000},
+	}
+
+	missing := missingWeightEntries(source, target)
+	assert.Equal(t, []garmin.WeightEntry{source[1], source[2]}, missing)
+	assert.Len(t, missingWeightEntries(source, source), 0)
+}
@@ -0,0 +1,185 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"github.com/sirupsen/logrus"
+	"github.com/yqt/garmin-intl2cn/garmin"
+	"sync"
+)
+
+type transferJob struct {
+	index int
+	item  garmin.ActivityListItem
+}
+
+type fetchedActivity struct {
+	transferJob
+	activity garmin.Activity
+	files    []garmin.ActivityFile
+	// name is set when a transformer renamed the activity
+	name string
+}
+
+type transferResult struct {
+	ActivityId int64
+	Err        error
+}
+
+// transferActivities pipelines fetches from source into uploads to target,
+// applying o.transformers in between. At most o.workers fetches and o.workers
+// uploads run at the same time, and results are returned in the same order as
+// items regardless of which transfer finishes first.
+func transferActivities(ctx context.Context, source Source, target Target, items []garmin.ActivityListItem, o *options) []transferResult {
+	results := make([]transferResult, len(items))
+	jobs := make(chan transferJob)
+	fetched := make(chan fetchedActivity)
+
+	if o.linkGear {
+		linkGear(source, target, o.history)
+	}
+	fetchDetails(source, target, o.transformers)
+	var transformer Transformer
+	if len(o.transformers) > 0 {
+		transformer = Chain(o.transformers...)
+	}
+
+	var fetchWg, uploadWg sync.WaitGroup
+	for i := 0; i < o.workers; i++ {
+		fetchWg.Add(1)
+		go func() {
+			defer fetchWg.Done()
+			for job := range jobs {
+				activity, files, err := source.Fetch(ctx, job.item)
+				if err != nil {
+					logrus.WithFields(logrus.Fields{
+						"activityId": job.item.ActivityId,
+						"err":        err,
+					}).Error("activity download failed")
+					results[job.index] = transferResult{ActivityId: job.item.ActivityId, Err: err}
+					continue
+				}
+				name := ""
+				if transformer != nil {
+					var transformed garmin.Activity
+					transformed, files, err = transformActivity(transformer, activity, files)
+					if err != nil {
+						logrus.WithFields(logrus.Fields{
+							"activityId": job.item.ActivityId,
+							"err":        err,
+						}).Error("activity transform failed")
+						results[job.index] = transferResult{ActivityId: job.item.ActivityId, Err: err}
+						continue
+					}
+					if transformed.ActivityName != activity.ActivityName {
+						name = transformed.ActivityName
+					}
+					activity = transformed
+				}
+				fetched <- fetchedActivity{
+					transferJob: job,
+					activity:    activity,
+					files:       files,
+					name:        name,
+				}
+			}
+		}()
+	}
+
+	for i := 0; i < o.workers; i++ {
+		uploadWg.Add(1)
+		go func() {
+			defer uploadWg.Done()
+			for f := range fetched {
+				var err error
+				if named, ok := target.(namingTarget); ok {
+					err = named.uploadNamed(ctx, f.activity, f.files, f.name)
+				} else {
+					err = target.Upload(ctx, f.activity, f.files)
+				}
+				if err != nil && !errors.Is(err, garmin.ErrDuplicateActivity) {
+					logrus.WithFields(logrus.Fields{
+						"activityId": f.item.ActivityId,
+						"err":        err,
+					}).Error("activity upload failed")
+				}
+				results[f.index] = transferResult{ActivityId: f.item.ActivityId, Err: err}
+			}
+		}()
+	}
+
+	for i, item := range items {
+		jobs <- transferJob{index: i, item: item}
+	}
+	close(jobs)
+	fetchWg.Wait()
+	close(fetched)
+	uploadWg.Wait()
+
+	return results
+}
+
+// transformActivity runs every file through transformer along with the original
+// metadata of the activity, so that transformers editing the metadata, such as
+// RenameTransformer, apply once however many files there are. The metadata
+// returned for the first file is kept.
+func transformActivity(transformer Transformer, activity garmin.Activity, files []garmin.ActivityFile) (garmin.Activity, []garmin.ActivityFile, error) {
+	transformedActivity := activity
+	transformed := make([]garmin.ActivityFile, 0, len(files))
+	for i, file := range files {
+		file, fileActivity, err := transformer.Transform(file, activity)
+		if err != nil {
+			return garmin.Activity{}, nil, err
+		}
+		if i == 0 {
+			transformedActivity = fileActivity
+		}
+		transformed = append(transformed, file)
+	}
+	return transformedActivity, transformed, nil
+}
+
+// uploadActivityFiles uploads every file of a (possibly multi-file) activity and
+// names the first created activity after name, if set. The activity only counts
+// as a duplicate if the target already had all of the files. The id of the first
+// created activity is returned, 0 if garmin was still processing it.
+func uploadActivityFiles(target *garmin.Client, files []garmin.ActivityFile, name string) (int64, error) {
+	duplicates := 0
+	var uploadedId int64
+	for _, file := range files {
+		id, err := target.UploadActivity(file.FileName, file.Reader())
+		if errors.Is(err, garmin.ErrDuplicateActivity) {
+			duplicates++
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+		if uploadedId == 0 {
+			uploadedId = id
+		}
+	}
+	if duplicates == len(files) {
+		return 0, garmin.ErrDuplicateActivity
+	}
+
+	if name == "" {
+		return uploadedId, nil
+	}
+	if uploadedId == 0 {
+		logrus.WithFields(logrus.Fields{
+			"name": name,
+		}).Warn("uploaded activity is still processing, rename skipped")
+		return 0, nil
+	}
+	// NOTE: the activity itself is uploaded, failing here would only make retries
+	// run into duplicates and never rename it
+	if err := target.UpdateActivityName(uploadedId, name); err != nil {
+		logrus.WithFields(logrus.Fields{
+			"activityId": uploadedId,
+			"name":       name,
+			"err":        err,
+		}).Error("uploaded activity rename failed")
+	}
+	return uploadedId, nil
+}
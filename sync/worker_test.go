@@ -0,0 +1,138 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/yqt/garmin-intl2cn/garmin"
+	"sync"
+	"testing"
+	"time"
+)
+
+// concurrencyCounter records the most calls running at the same time.
+type concurrencyCounter struct {
+	mu      sync.Mutex
+	running int
+	max     int
+}
+
+func (c *concurrencyCounter) enter() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.running++
+	if c.running > c.max {
+		c.max = c.running
+	}
+}
+
+func (c *concurrencyCounter) leave() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.running--
+}
+
+type slowSource struct {
+	fetches concurrencyCounter
+	failing map[int64]bool
+}
+
+func (s *slowSource) List(ctx context.Context, filter garmin.ActivityFilter) ([]garmin.ActivityListItem, error) {
+	return nil, nil
+}
+
+func (s *slowSource) Fetch(ctx context.Context, item garmin.ActivityListItem) (garmin.Activity, []garmin.ActivityFile, error) {
+	s.fetches.enter()
+	defer s.fetches.leave()
+	// NOTE: earlier items take longer, so they finish last
+	time.Sleep(time.Duration(10-item.ActivityId) * time.Millisecond)
+	if s.failing[item.ActivityId] {
+		return garmin.Activity{}, nil, errors.New("download failed")
+	}
+	return garmin.Activity{ActivityId: item.ActivityId}, []garmin.ActivityFile{garmin.NewActivityFile("activity.fit", []byte("fit"))}, nil
+}
+
+type slowTarget struct {
+	uploads concurrencyCounter
+}
+
+func (t *slowTarget) Exists(ctx context.Context, item garmin.ActivityListItem) (bool, error) {
+	return false, nil
+}
+
+func (t *slowTarget) Upload(ctx context.Context, activity garmin.Activity, files []garmin.ActivityFile) error {
+	t.uploads.enter()
+	defer t.uploads.leave()
+	time.Sleep(time.Duration(10-activity.ActivityId) * time.Millisecond)
+	return nil
+}
+
+func TestTransferActivities_OrderAndWorkers(t *testing.T) {
+	items := make([]garmin.ActivityListItem, 0)
+	for id := int64(1); id <= 8; id++ {
+		items = append(items, garmin.ActivityListItem{ActivityId: id})
+	}
+	source := &slowSource{failing: map[int64]bool{3: true}}
+	target := &slowTarget{}
+
+	results := transferActivities(context.Background(), source, target, items, newOptions(Workers(3)))
+	assert.Len(t, results, len(items))
+	for i, result := range results {
+		assert.Equal(t, items[i].ActivityId, result.ActivityId)
+		if result.ActivityId == 3 {
+			assert.NotNil(t, result.Err)
+		} else {
+			assert.Nil(t, result.Err)
+		}
+	}
+	assert.True(t, source.fetches.max > 1)
+	assert.True(t, source.fetches.max <= 3)
+	assert.True(t, target.uploads.max <= 3)
+}
+
+type namingFakeTarget struct {
+	slowTarget
+	mu    sync.Mutex
+	names map[int64]string
+}
+
+func (t *namingFakeTarget) uploadNamed(ctx context.Context, activity garmin.Activity, files []garmin.ActivityFile, name string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.names[activity.ActivityId] = name
+	return nil
+}
+
+func TestTransferActivities_RenameOnlyChangedNames(t *testing.T) {
+	items := []garmin.ActivityListItem{{ActivityId: 1}, {ActivityId: 2}}
+	keep := TransformerFunc(func(file garmin.ActivityFile, activity garmin.Activity) (garmin.ActivityFile, garmin.Activity, error) {
+		return file, activity, nil
+	})
+	rename, err := RenameTransformer("Run {{.ActivityId}}")
+	assert.Nil(t, err)
+
+	target := &namingFakeTarget{names: make(map[int64]string)}
+	transferActivities(context.Background(), &slowSource{}, target, items, newOptions(Transformers(keep)))
+	assert.Equal(t, map[int64]string{1: "", 2: ""}, target.names)
+
+	transferActivities(context.Background(), &slowSource{}, target, items, newOptions(Transformers(keep, rename)))
+	assert.Equal(t, map[int64]string{1: "Run 1", 2: "Run 2"}, target.names)
+}
+
+func TestFetchDetails(t *testing.T) {
+	keep := TransformerFunc(func(file garmin.ActivityFile, activity garmin.Activity) (garmin.ActivityFile, garmin.Activity, error) {
+		return file, activity, nil
+	})
+	rename, err := RenameTransformer("{{.ActivityName}}")
+	assert.Nil(t, err)
+
+	source := NewGarminSource(nil)
+	fetchDetails(source, NewGarminTarget(nil), []Transformer{keep})
+	assert.False(t, source.details)
+	fetchDetails(source, NewGarminTarget(nil), []Transformer{keep, rename})
+	assert.True(t, source.details)
+
+	source = NewGarminSource(nil)
+	fetchDetails(source, NewStorageTarget(nil), nil)
+	assert.True(t, source.details)
+}
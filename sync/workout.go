@@ -0,0 +1,177 @@
+package sync
+
+import (
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"github.com/yqt/garmin-intl2cn/garmin"
+	"strings"
+	"time"
+)
+
+const workoutPageSize = 100
+
+// SynchronizeWorkouts copies the workouts of the international account, with
+// their steps, to the CN account. When scheduleDays is positive, workouts planned
+// on the calendar for the next scheduleDays days are planned on the CN calendar too.
+// Copies are recorded in the history set by SyncHistory, and matched by name otherwise.
+func SynchronizeWorkouts(userInfo UserInfo, scheduleDays int, opts ...Option) (bool, string, error) {
+	o := newOptions(opts...)
+	clientIntl, clientCn := newClients(userInfo, o)
+
+	if err := clientIntl.Auth(false); err != nil {
+		return false, "", err
+	}
+	if err := clientCn.Auth(false); err != nil {
+		return false, "", err
+	}
+	if err := checkSameOwner(clientIntl, clientCn, o); err != nil {
+		return false, "", err
+	}
+
+	intlWorkouts, err := allWorkouts(clientIntl)
+	if err != nil {
+		return false, "", err
+	}
+	cnWorkouts, err := allWorkouts(clientCn)
+	if err != nil {
+		return false, "", err
+	}
+	cnWorkoutIds := make(map[string]int64)
+	for _, workout := range cnWorkouts {
+		cnWorkoutIds[workout.WorkoutName] = workout.WorkoutId
+	}
+
+	succeeded := make([]string, 0)
+	failed := make([]string, 0)
+	skipped := make([]string, 0)
+	// targetIds maps the id of each source workout to its CN copy
+	targetIds := make(map[int64]int64)
+	for _, workout := range intlWorkouts {
+		if entry, ok := lookupHistory(o.history, HistoryKindWorkout, workout.WorkoutId); ok {
+			targetIds[workout.WorkoutId] = entry.TargetId
+			skipped = append(skipped, workout.WorkoutName)
+			continue
+		}
+		if id, ok := cnWorkoutIds[workout.WorkoutName]; ok {
+			targetIds[workout.WorkoutId] = id
+			recordHistory(o.history, HistoryKindWorkout, workout.WorkoutId, id, workout.WorkoutName)
+			skipped = append(skipped, workout.WorkoutName)
+			continue
+		}
+
+		created, err := copyWorkout(clientIntl, clientCn, workout.WorkoutId)
+		if err != nil {
+			logrus.WithFields(logrus.Fields{
+				"workoutId": workout.WorkoutId,
+				"err":       err,
+			}).Error("workout copy failed")
+			failed = append(failed, workout.WorkoutName)
+			continue
+		}
+		targetIds[workout.WorkoutId] = created.WorkoutId
+		recordHistory(o.history, HistoryKindWorkout, workout.WorkoutId, created.WorkoutId, workout.WorkoutName)
+		succeeded = append(succeeded, workout.WorkoutName)
+	}
+
+	msg := fmt.Sprintf(
+		"workouts[%s] succeeded. workouts[%s] failed. workouts[%s] skipped.",
+		strings.Join(succeeded, ", "), strings.Join(failed, ", "), strings.Join(skipped, ", "))
+
+	succeededCount, failedCount := len(succeeded), len(failed)
+	if scheduleDays > 0 {
+		scheduled, scheduleFailed, err := copySchedules(clientIntl, clientCn, targetIds, scheduleDays, o)
+		if err != nil {
+			return false, msg, err
+		}
+		msg += fmt.Sprintf(" %d schedules succeeded. %d schedules failed.", scheduled, scheduleFailed)
+		succeededCount += scheduled
+		failedCount += scheduleFailed
+	}
+
+	suc := true
+	if succeededCount == 0 && failedCount != 0 {
+		suc = false
+	}
+	return suc, msg, nil
+}
+
+func copyWorkout(source *garmin.Client, target *garmin.Client, workoutId int64) (garmin.Workout, error) {
+	workout, err := source.GetWorkout(workoutId)
+	if err != nil {
+		return garmin.Workout{}, err
+	}
+	return target.CreateWorkout(workout)
+}
+
+// copySchedules plans copied workouts on the CN calendar the way they are planned
+// on the source calendar, and returns how many schedules were created and failed.
+func copySchedules(source *garmin.Client, target *garmin.Client, targetIds map[int64]int64, days int, o *options) (int, int, error) {
+	start := time.Now()
+	end := start.AddDate(0, 0, days)
+	scheduled, err := source.GetScheduledWorkouts(start, end)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	succeeded, failed := 0, 0
+	for _, item := range scheduled {
+		if _, ok := lookupHistory(o.history, HistoryKindSchedule, item.Id); ok {
+			continue
+		}
+		targetId, ok := targetIds[item.WorkoutId]
+		if !ok {
+			continue
+		}
+		date, err := time.Parse("2006-01-02", item.Date)
+		if err != nil {
+			return succeeded, failed, err
+		}
+		scheduleId, err := target.ScheduleWorkout(targetId, date)
+		if err != nil {
+			logrus.WithFields(logrus.Fields{
+				"workoutId": item.WorkoutId,
+				"date":      item.Date,
+				"err":       err,
+			}).Error("workout schedule failed")
+			failed++
+			continue
+		}
+		recordHistory(o.history, HistoryKindSchedule, item.Id, scheduleId, item.Title)
+		succeeded++
+	}
+	return succeeded, failed, nil
+}
+
+func allWorkouts(client *garmin.Client) ([]garmin.Workout, error) {
+	workouts := make([]garmin.Workout, 0)
+	for start := int64(0); ; start += workoutPageSize {
+		page, err := client.GetWorkouts(start, workoutPageSize)
+		if err != nil {
+			return nil, err
+		}
+		workouts = append(workouts, page...)
+		if len(page) < workoutPageSize {
+			return workouts, nil
+		}
+	}
+}
+
+func lookupHistory(h *History, kind string, sourceId int64) (HistoryEntry, bool) {
+	if h == nil {
+		return HistoryEntry{}, false
+	}
+	return h.Lookup(kind, sourceId)
+}
+
+func recordHistory(h *History, kind string, sourceId int64, targetId int64, name string) {
+	if h == nil {
+		return
+	}
+	if err := h.Record(kind, sourceId, targetId, name); err != nil {
+		logrus.WithFields(logrus.Fields{
+			"kind":     kind,
+			"sourceId": sourceId,
+			"err":      err,
+		}).Error("sync history update failed")
+	}
+}
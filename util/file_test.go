@@ -0,0 +1,31 @@
+package util
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestJSONFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jsonfile")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "entries.json")
+
+	entries := []int{1, 2}
+	assert.Nil(t, ReadJSONFile(path, &entries))
+	assert.Equal(t, []int{1, 2}, entries)
+
+	assert.Nil(t, WriteJSONFile(path, []int{3, 4, 5}))
+	content, err := ioutil.ReadFile(path)
+	assert.Nil(t, err)
+	assert.Equal(t, "[\n  3,\n  4,\n  5\n]", string(content))
+
+	assert.Nil(t, ReadJSONFile(path, &entries))
+	assert.Equal(t, []int{3, 4, 5}, entries)
+
+	assert.Nil(t, ioutil.WriteFile(path, []byte("{"), 0644))
+	assert.NotNil(t, ReadJSONFile(path, &entries))
+}
@@ -0,0 +1,132 @@
+package util
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+type RateLimit struct {
+	// Sustained number of requests allowed per minute
+	RequestsPerMinute int
+	// Number of requests that may be sent back to back before pacing kicks in
+	Burst int
+	// Bounds of the random pause added before every request
+	MinDelay time.Duration
+	MaxDelay time.Duration
+}
+
+var DefaultRateLimit = RateLimit{
+	RequestsPerMinute: 30,
+	Burst:             5,
+	MinDelay:          500 * time.Millisecond,
+	MaxDelay:          2 * time.Second,
+}
+
+// RateLimiter is a token bucket refilled at RequestsPerMinute with a capacity of Burst.
+type RateLimiter struct {
+	mu     sync.Mutex
+	limit  RateLimit
+	tokens float64
+	last   time.Time
+
+	now   func() time.Time
+	sleep func(time.Duration)
+	rand  func(int64) int64
+}
+
+// rateLimiterKey keeps clients configured with other limits, e.g. in tests,
+// from changing the pace of each other.
+type rateLimiterKey struct {
+	host  string
+	limit RateLimit
+}
+
+var (
+	hostLimitersMu sync.Mutex
+	hostLimiters   = make(map[rateLimiterKey]*RateLimiter)
+)
+
+func NewRateLimiter(limit RateLimit) *RateLimiter {
+	return &RateLimiter{
+		limit:  limit,
+		tokens: float64(limit.Burst),
+		now:    time.Now,
+		sleep:  time.Sleep,
+		rand:   rand.Int63n,
+	}
+}
+
+// HostRateLimiter returns the limiter shared by every request sent to host with
+// limit, so that all the clients of the process talking to the same Garmin host
+// are paced together, however many of them are built.
+func HostRateLimiter(host string, limit RateLimit) *RateLimiter {
+	hostLimitersMu.Lock()
+	defer hostLimitersMu.Unlock()
+
+	key := rateLimiterKey{host: host, limit: limit}
+	limiter, ok := hostLimiters[key]
+	if !ok {
+		limiter = NewRateLimiter(limit)
+		hostLimiters[key] = limiter
+	}
+	return limiter
+}
+
+func (l *RateLimiter) SetLimit(limit RateLimit) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.limit == limit {
+		return
+	}
+	l.limit = limit
+	if l.tokens > float64(limit.Burst) {
+		l.tokens = float64(limit.Burst)
+	}
+}
+
+// Wait blocks until a request may be sent.
+func (l *RateLimiter) Wait() {
+	if d := l.reserve(); d > 0 {
+		l.sleep(d)
+	}
+}
+
+// reserve takes a token and returns how long the caller must wait before using it.
+func (l *RateLimiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delay := l.jitter()
+	if l.limit.RequestsPerMinute <= 0 {
+		return delay
+	}
+
+	now := l.now()
+	perMinute := float64(l.limit.RequestsPerMinute)
+	if !l.last.IsZero() {
+		l.tokens += float64(now.Sub(l.last)) * perMinute / float64(time.Minute)
+	}
+	burst := float64(l.limit.Burst)
+	if burst < 1 {
+		burst = 1
+	}
+	if l.tokens > burst {
+		l.tokens = burst
+	}
+	l.last = now
+
+	l.tokens--
+	if l.tokens < 0 {
+		delay += time.Duration(-l.tokens * float64(time.Minute) / perMinute)
+	}
+	return delay
+}
+
+func (l *RateLimiter) jitter() time.Duration {
+	if l.limit.MaxDelay <= l.limit.MinDelay {
+		return l.limit.MinDelay
+	}
+	return l.limit.MinDelay + time.Duration(l.rand(int64(l.limit.MaxDelay-l.limit.MinDelay)))
+}
@@ -0,0 +1,80 @@
+package util
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func newTestRateLimiter(limit RateLimit, now *time.Time) *RateLimiter {
+	limiter := NewRateLimiter(limit)
+	limiter.now = func() time.Time {
+		return *now
+	}
+	limiter.rand = func(n int64) int64 {
+		return n / 2
+	}
+	return limiter
+}
+
+func TestRateLimiter_Burst(t *testing.T) {
+	now := time.Unix(1620000000, 0)
+	limiter := newTestRateLimiter(RateLimit{RequestsPerMinute: 60, Burst: 3}, &now)
+
+	assert.Equal(t, time.Duration(0), limiter.reserve())
+	assert.Equal(t, time.Duration(0), limiter.reserve())
+	assert.Equal(t, time.Duration(0), limiter.reserve())
+	assert.Equal(t, time.Second, limiter.reserve())
+	assert.Equal(t, 2*time.Second, limiter.reserve())
+}
+
+func TestRateLimiter_Refill(t *testing.T) {
+	now := time.Unix(1620000000, 0)
+	limiter := newTestRateLimiter(RateLimit{RequestsPerMinute: 60, Burst: 1}, &now)
+
+	assert.Equal(t, time.Duration(0), limiter.reserve())
+	now = now.Add(time.Second)
+	assert.Equal(t, time.Duration(0), limiter.reserve())
+	now = now.Add(time.Minute)
+	assert.Equal(t, time.Duration(0), limiter.reserve())
+	assert.Equal(t, time.Second, limiter.reserve())
+}
+
+func TestRateLimiter_Jitter(t *testing.T) {
+	now := time.Unix(1620000000, 0)
+	limiter := newTestRateLimiter(RateLimit{MinDelay: time.Second, MaxDelay: 3 * time.Second}, &now)
+
+	assert.Equal(t, 2*time.Second, limiter.reserve())
+}
+
+func TestCookieRequest_RateLimitSharedPerHost(t *testing.T) {
+	limit := RateLimit{RequestsPerMinute: 60, Burst: 1}
+	a := NewCookieRequest()
+	b := NewCookieRequest()
+	a.SetRateLimit(limit)
+	b.SetRateLimit(limit)
+
+	// separately built clients are paced by one bucket per host
+	assert.Same(t, a.limiter("connect.garmin.com"), b.limiter("connect.garmin.com"))
+	assert.NotSame(t, a.limiter("connect.garmin.com"), a.limiter("connect.garmin.cn"))
+
+	limiter := a.limiter("connect.garmin.com")
+	now := time.Unix(1620000000, 0)
+	limiter.now = func() time.Time {
+		return now
+	}
+	limiter.rand = func(n int64) int64 {
+		return 0
+	}
+	assert.Equal(t, time.Duration(0), a.limiter("connect.garmin.com").reserve())
+	assert.Equal(t, time.Second, b.limiter("connect.garmin.com").reserve())
+
+	// other limits do not change the pace of the shared bucket
+	c := NewCookieRequest()
+	c.SetRateLimit(RateLimit{RequestsPerMinute: 10, Burst: 2})
+	assert.NotSame(t, a.limiter("connect.garmin.com"), c.limiter("connect.garmin.com"))
+	assert.Equal(t, 60, a.limiter("connect.garmin.com").limit.RequestsPerMinute)
+	assert.Equal(t, 10, c.limiter("connect.garmin.com").limit.RequestsPerMinute)
+
+	assert.Nil(t, NewCookieRequest().limiter("connect.garmin.com"))
+}
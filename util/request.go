@@ -9,11 +9,14 @@ import (
 	"github.com/sirupsen/logrus"
 	"io"
 	"io/ioutil"
+	"mime"
 	"mime/multipart"
 	"net/http"
 	"net/http/cookiejar"
 	neturl "net/url"
-	"strconv"
+	"path"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -22,15 +25,22 @@ type Request interface {
 	GetJson(string, map[string]interface{}, interface{}) error
 	Post(string, map[string]interface{}, map[string]interface{}, []byte, bool) (string, error)
 	PostJson(string, map[string]interface{}, map[string]interface{}, []byte, bool, interface{}) error
+	Put(string, map[string]interface{}, map[string]interface{}, []byte, bool) (string, error)
 	GetFile(string, map[string]interface{}) ([]byte, error)
+	GetFileWithName(string, map[string]interface{}) ([]byte, string, error)
 	UploadFile(string, map[string]interface{}, string, string, io.ReadCloser) (string, error)
+	UploadFileWithHeaders(string, map[string]interface{}, string, string, io.ReadCloser, map[string]string) (string, error)
 	SetHeaders(map[string]string)
 	UpdateHeaders(map[string]string)
 }
 
 type CookieRequest struct {
-	headers map[string]string
-	client  *http.Client
+	headers     map[string]string
+	rateLimit   *RateLimit
+	retryPolicy RetryPolicy
+	client      *http.Client
+	mu          *sync.RWMutex
+	sleep       func(time.Duration)
 }
 
 func NewCookieRequest() *CookieRequest {
@@ -47,17 +57,20 @@ func NewCookieRequest() *CookieRequest {
 		},
 	}
 	return &CookieRequest{
-		headers: make(map[string]string),
-		client:  client,
+		headers:     make(map[string]string),
+		retryPolicy: NoRetry,
+		client:      client,
+		mu:          &sync.RWMutex{},
+		sleep:       time.Sleep,
 	}
 }
 
 func (c *CookieRequest) Get(url string, params map[string]interface{}) (string, error) {
-	return c.requestText(url, http.MethodGet, params, nil, nil, false)
+	return c.requestText(url, http.MethodGet, params, nil, nil, false, nil)
 }
 
 func (c *CookieRequest) GetJson(url string, params map[string]interface{}, dataOut interface{}) error {
-	respText, err := c.requestText(url, http.MethodGet, params, nil, nil, true)
+	respText, err := c.requestText(url, http.MethodGet, params, nil, nil, true, nil)
 	if err != nil {
 		return err
 	}
@@ -66,11 +79,11 @@ func (c *CookieRequest) GetJson(url string, params map[string]interface{}, dataO
 }
 
 func (c *CookieRequest) Post(url string, params map[string]interface{}, data map[string]interface{}, rawBody []byte, sendJson bool) (string, error) {
-	return c.requestText(url, http.MethodPost, params, data, rawBody, sendJson)
+	return c.requestText(url, http.MethodPost, params, data, rawBody, sendJson, nil)
 }
 
 func (c *CookieRequest) PostJson(url string, params map[string]interface{}, data map[string]interface{}, rawBody []byte, sendJson bool, dataOut interface{}) error {
-	respText, err := c.requestText(url, http.MethodPost, params, data, rawBody, sendJson)
+	respText, err := c.requestText(url, http.MethodPost, params, data, rawBody, sendJson, nil)
 	if err != nil {
 		return err
 	}
@@ -78,24 +91,58 @@ func (c *CookieRequest) PostJson(url string, params map[string]interface{}, data
 	return json.Unmarshal([]byte(respText), dataOut)
 }
 
+func (c *CookieRequest) Put(url string, params map[string]interface{}, data map[string]interface{}, rawBody []byte, sendJson bool) (string, error) {
+	return c.requestText(url, http.MethodPut, params, data, rawBody, sendJson, nil)
+}
+
 func (c *CookieRequest) GetFile(url string, params map[string]interface{}) ([]byte, error) {
-	resp, err := c.request(url, http.MethodGet, params, nil, nil, false)
+	body, _, err := c.GetFileWithName(url, params)
+	return body, err
+}
+
+// GetFileWithName also returns the file name suggested by the Content-Disposition header, if any.
+func (c *CookieRequest) GetFileWithName(url string, params map[string]interface{}) ([]byte, string, error) {
+	resp, err := c.do(url, http.MethodGet, params, nil, nil, false, nil)
 	if err != nil {
 		logrus.Error(err)
-		return nil, err
+		return nil, "", err
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		logrus.Errorf("invalid status code[%d]", resp.StatusCode)
+		return nil, "", &StatusError{StatusCode: resp.StatusCode}
+	}
 
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		logrus.Error(err)
-		return nil, err
+		return nil, "", err
+	}
+
+	fileName := ""
+	if _, params, err := mime.ParseMediaType(resp.Header.Get("Content-Disposition")); err == nil {
+		fileName = baseFileName(params["filename"])
 	}
 
-	return body, nil
+	return body, fileName, nil
+}
+
+// baseFileName drops any directory of a file name suggested by a server, so it
+// can't point outside of where the file is saved.
+func baseFileName(fileName string) string {
+	fileName = path.Base(strings.ReplaceAll(fileName, "\\", "/"))
+	if fileName == "." || fileName == "/" || fileName == ".." {
+		return ""
+	}
+	return fileName
 }
 
 func (c *CookieRequest) UploadFile(url string, params map[string]interface{}, fileParamName string, fileName string, file io.ReadCloser) (string, error) {
+	return c.UploadFileWithHeaders(url, params, fileParamName, fileName, file, nil)
+}
+
+// UploadFileWithHeaders also sends headers, for this request only.
+func (c *CookieRequest) UploadFileWithHeaders(url string, params map[string]interface{}, fileParamName string, fileName string, file io.ReadCloser, headers map[string]string) (string, error) {
 	defer file.Close()
 
 	body := &bytes.Buffer{}
@@ -118,30 +165,76 @@ func (c *CookieRequest) UploadFile(url string, params map[string]interface{}, fi
 		return "", err
 	}
 
-	c.headers["Content-Type"] = writer.FormDataContentType()
-	return c.requestText(url, http.MethodPost, nil, nil, body.Bytes(), false)
+	// NOTE: the multipart boundary differs per upload, so it must not leak into the shared headers
+	requestHeaders := map[string]string{
+		"Content-Type": writer.FormDataContentType(),
+	}
+	for key, val := range headers {
+		requestHeaders[key] = val
+	}
+	return c.requestText(url, http.MethodPost, nil, nil, body.Bytes(), false, requestHeaders)
 }
 
 func (c *CookieRequest) SetHeaders(headers map[string]string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	c.headers = headers
 }
 
 func (c *CookieRequest) UpdateHeaders(headers map[string]string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	for k, v := range headers {
 		c.headers[k] = v
 	}
 }
 
-func (c *CookieRequest) requestText(url string, method string, params map[string]interface{}, data interface{}, rawBody []byte, sendJson bool) (string, error) {
-	resp, err := c.request(url, method, params, data, rawBody, sendJson)
+// SetRateLimit paces every following request through the limiter of its host,
+// shared with the other requests of the process using the same limit, see HostRateLimiter.
+func (c *CookieRequest) SetRateLimit(limit RateLimit) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rateLimit = &limit
+}
+
+// limiter returns the limiter of host, nil if requests are not paced.
+func (c *CookieRequest) limiter(host string) *RateLimiter {
+	c.mu.RLock()
+	rateLimit := c.rateLimit
+	c.mu.RUnlock()
+	if rateLimit == nil {
+		return nil
+	}
+	return HostRateLimiter(host, *rateLimit)
+}
+
+func (c *CookieRequest) SetRetryPolicy(policy RetryPolicy) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.retryPolicy = policy
+}
+
+// WithRetryPolicy returns a request overriding the retry policy for a single call.
+// It shares cookies and headers with c and is not meant to be kept around.
+func (c *CookieRequest) WithRetryPolicy(policy RetryPolicy) *CookieRequest {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	clone := *c
+	clone.retryPolicy = policy
+	return &clone
+}
+
+func (c *CookieRequest) requestText(url string, method string, params map[string]interface{}, data interface{}, rawBody []byte, sendJson bool, headers map[string]string) (string, error) {
+	resp, err := c.do(url, method, params, data, rawBody, sendJson, headers)
 	if err != nil {
 		return "", err
 	}
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusAccepted &&
+		resp.StatusCode != http.StatusNoContent {
 		logrus.Errorf("invalid status code[%d]", resp.StatusCode)
-		return "", errors.New("invalid status code: " + strconv.Itoa(resp.StatusCode))
+		return "", &StatusError{StatusCode: resp.StatusCode}
 	}
-	defer resp.Body.Close()
 	bodyBytes, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		logrus.Error(err)
@@ -154,7 +247,44 @@ func (c *CookieRequest) requestText(url string, method string, params map[string
 	return respText, nil
 }
 
-func (c *CookieRequest) request(url string, method string, params map[string]interface{}, data interface{}, rawBody []byte, sendJson bool) (*http.Response, error) {
+// do sends the request, retrying transient failures according to the retry policy.
+func (c *CookieRequest) do(url string, method string, params map[string]interface{}, data interface{}, rawBody []byte, sendJson bool, headers map[string]string) (*http.Response, error) {
+	c.mu.RLock()
+	policy := c.retryPolicy
+	c.mu.RUnlock()
+
+	for attempt := 1; ; attempt++ {
+		resp, err := c.request(url, method, params, data, rawBody, sendJson, headers)
+		if err == nil && !retryableStatus(resp.StatusCode) {
+			return resp, nil
+		}
+		if attempt >= policy.MaxAttempts || !policy.allows(method) || (err != nil && !retryableError(err)) {
+			return resp, err
+		}
+
+		var retryAfter time.Duration
+		if err == nil {
+			retryAfter = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+		}
+		delay, ok := policy.backoff(attempt-1, retryAfter, randInt63n)
+		if err == nil {
+			if !ok {
+				return resp, nil
+			}
+			_ = resp.Body.Close()
+			err = &StatusError{StatusCode: resp.StatusCode, RetryAfter: retryAfter}
+		}
+		logrus.WithFields(logrus.Fields{
+			"url":     url,
+			"attempt": attempt,
+			"delay":   delay,
+			"err":     err,
+		}).Warn("request failed, retrying")
+		c.sleep(delay)
+	}
+}
+
+func (c *CookieRequest) request(url string, method string, params map[string]interface{}, data interface{}, rawBody []byte, sendJson bool, headers map[string]string) (*http.Response, error) {
 	var buffer *bytes.Buffer
 
 	if data != nil {
@@ -200,10 +330,13 @@ func (c *CookieRequest) request(url string, method string, params map[string]int
 		req.URL.RawQuery = q.Encode()
 	}
 	//add headers
-	if c.headers != nil {
-		for key, val := range c.headers {
-			req.Header.Set(key, val)
-		}
+	c.mu.RLock()
+	for key, val := range c.headers {
+		req.Header.Set(key, val)
+	}
+	c.mu.RUnlock()
+	for key, val := range headers {
+		req.Header.Set(key, val)
 	}
 
 	if sendJson {
@@ -212,6 +345,10 @@ func (c *CookieRequest) request(url string, method string, params map[string]int
 		req.Header.Set("Content-type", "application/x-www-form-urlencoded")
 	}
 
+	if limiter := c.limiter(req.URL.Host); limiter != nil {
+		limiter.Wait()
+	}
+
 	logrus.Debugf("Go %s URL : %s \n", method, req.URL.String())
 	return c.client.Do(req)
 }
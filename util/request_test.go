@@ -0,0 +1,31 @@
+package util
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCookieRequest_GetFileWithName(t *testing.T) {
+	disposition := ""
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Disposition", disposition)
+		_, _ = w.Write([]byte("data"))
+	}))
+	defer server.Close()
+
+	for header, fileName := range map[string]string{
+		`attachment; filename="123.gpx"`:           "123.gpx",
+		`attachment; filename="../../etc/123.gpx"`: "123.gpx",
+		`attachment; filename="C:\\tmp\\123.tcx"`:  "123.tcx",
+		`attachment; filename=".."`:                "",
+		"":                                         "",
+	} {
+		disposition = header
+		data, name, err := NewCookieRequest().GetFileWithName(server.URL, nil)
+		assert.Nil(t, err)
+		assert.Equal(t, []byte("data"), data)
+		assert.Equal(t, fileName, name, header)
+	}
+}
@@ -0,0 +1,113 @@
+package util
+
+import (
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+type RetryPolicy struct {
+	// Total number of attempts, including the first one
+	MaxAttempts int
+	// Backoff before the n-th retry is a random duration in [0, BaseDelay * 2^n), capped by MaxDelay
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// Retry requests that are not idempotent (e.g. uploads) as well. Only enable it
+	// when the caller can tell a duplicate created by an attempt whose response was lost.
+	RetryNonIdempotent bool
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   2 * time.Second,
+	MaxDelay:    time.Minute,
+}
+
+var NoRetry = RetryPolicy{
+	MaxAttempts: 1,
+}
+
+type StatusError struct {
+	StatusCode int
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return "invalid status code: " + strconv.Itoa(e.StatusCode)
+}
+
+func (p RetryPolicy) allows(method string) bool {
+	if p.RetryNonIdempotent {
+		return true
+	}
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// backoff returns the delay before retry number `retry` (starting from 0).
+// A server provided Retry-After wins if it is longer, unless it exceeds MaxDelay,
+// in which case false is returned and the request should not be retried.
+func (p RetryPolicy) backoff(retry int, retryAfter time.Duration, random func(int64) int64) (time.Duration, bool) {
+	if p.MaxDelay > 0 && retryAfter > p.MaxDelay {
+		return 0, false
+	}
+
+	ceiling := p.BaseDelay
+	for i := 0; i < retry && (p.MaxDelay <= 0 || ceiling < p.MaxDelay); i++ {
+		ceiling *= 2
+	}
+	if p.MaxDelay > 0 && ceiling > p.MaxDelay {
+		ceiling = p.MaxDelay
+	}
+
+	var delay time.Duration
+	if ceiling > 0 {
+		delay = time.Duration(random(int64(ceiling)))
+	}
+	if retryAfter > delay {
+		delay = retryAfter
+	}
+	return delay, true
+}
+
+func retryableStatus(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests || statusCode >= http.StatusInternalServerError
+}
+
+// retryableError reports whether a transport error is transient: a timeout or a
+// connection reset or closed by the server. Errors such as unknown hosts or
+// invalid certificates are not retried.
+func retryableError(err error) bool {
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, syscall.ECONNRESET) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// parseRetryAfter supports both the delay-seconds and the HTTP-date form of Retry-After.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+	return 0
+}
+
+var randInt63n = rand.Int63n
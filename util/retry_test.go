@@ -0,0 +1,153 @@
+package util
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func newRetryTestServer(failures int, statusCode int, retryAfter string) (*httptest.Server, *int) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls <= failures {
+			if retryAfter != "" {
+				w.Header().Set("Retry-After", retryAfter)
+			}
+			w.WriteHeader(statusCode)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	return server, &calls
+}
+
+func newRetryTestRequest(policy RetryPolicy, delays *[]time.Duration) *CookieRequest {
+	req := NewCookieRequest()
+	req.SetRetryPolicy(policy)
+	req.sleep = func(d time.Duration) {
+		*delays = append(*delays, d)
+	}
+	return req
+}
+
+func TestCookieRequest_RetryTransientStatus(t *testing.T) {
+	server, calls := newRetryTestServer(2, http.StatusServiceUnavailable, "")
+	defer server.Close()
+
+	delays := make([]time.Duration, 0)
+	req := newRetryTestRequest(RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}, &delays)
+
+	respText, err := req.Get(server.URL, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "ok", respText)
+	assert.Equal(t, 3, *calls)
+	assert.Len(t, delays, 2)
+}
+
+func TestCookieRequest_RetryAfter(t *testing.T) {
+	server, calls := newRetryTestServer(1, http.StatusTooManyRequests, "7")
+	defer server.Close()
+
+	delays := make([]time.Duration, 0)
+	req := newRetryTestRequest(RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond}, &delays)
+
+	_, err := req.Get(server.URL, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, *calls)
+	assert.Equal(t, []time.Duration{7 * time.Second}, delays)
+}
+
+func TestCookieRequest_RetryGivesUp(t *testing.T) {
+	server, calls := newRetryTestServer(5, http.StatusBadGateway, "")
+	defer server.Close()
+
+	delays := make([]time.Duration, 0)
+	req := newRetryTestRequest(RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond}, &delays)
+
+	_, err := req.Get(server.URL, nil)
+	statusErr, ok := err.(*StatusError)
+	assert.True(t, ok)
+	assert.Equal(t, http.StatusBadGateway, statusErr.StatusCode)
+	assert.Equal(t, 2, *calls)
+}
+
+func TestCookieRequest_NoRetryForNonIdempotent(t *testing.T) {
+	server, calls := newRetryTestServer(1, http.StatusServiceUnavailable, "")
+	defer server.Close()
+
+	delays := make([]time.Duration, 0)
+	req := newRetryTestRequest(RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}, &delays)
+
+	_, err := req.Post(server.URL, nil, map[string]interface{}{"a": 1}, nil, false)
+	assert.NotNil(t, err)
+	assert.Equal(t, 1, *calls)
+
+	respText, err := req.WithRetryPolicy(RetryPolicy{MaxAttempts: 3, RetryNonIdempotent: true}).
+		Post(server.URL, nil, map[string]interface{}{"a": 1}, nil, false)
+	assert.Nil(t, err)
+	assert.Equal(t, "ok", respText)
+	assert.Equal(t, 2, *calls)
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: time.Second, MaxDelay: 5 * time.Second}
+	ceiling := func(n int64) int64 {
+		return n
+	}
+
+	backoff := func(retry int, retryAfter time.Duration) time.Duration {
+		delay, ok := policy.backoff(retry, retryAfter, ceiling)
+		assert.True(t, ok)
+		return delay
+	}
+
+	assert.Equal(t, time.Second, backoff(0, 0))
+	assert.Equal(t, 4*time.Second, backoff(2, 0))
+	assert.Equal(t, 5*time.Second, backoff(10, 0))
+	assert.Equal(t, 3*time.Second, backoff(0, 3*time.Second))
+
+	_, ok := policy.backoff(0, 30*time.Second, ceiling)
+	assert.False(t, ok)
+}
+
+func TestCookieRequest_RetryAfterBeyondMaxDelay(t *testing.T) {
+	server, calls := newRetryTestServer(1, http.StatusTooManyRequests, "3600")
+	defer server.Close()
+
+	delays := make([]time.Duration, 0)
+	req := newRetryTestRequest(RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Minute}, &delays)
+
+	_, err := req.Get(server.URL, nil)
+	statusErr, ok := err.(*StatusError)
+	assert.True(t, ok)
+	assert.Equal(t, http.StatusTooManyRequests, statusErr.StatusCode)
+	assert.Equal(t, 1, *calls)
+	assert.Empty(t, delays)
+}
+
+func TestRetryableError(t *testing.T) {
+	assert.True(t, retryableError(&url.Error{Op: "Get", URL: "https://connect.garmin.com", Err: &net.DNSError{IsTimeout: true}}))
+	assert.True(t, retryableError(&url.Error{Op: "Get", URL: "https://connect.garmin.com",
+		Err: &net.OpError{Op: "read", Err: os.NewSyscallError("read", syscall.ECONNRESET)}}))
+	assert.True(t, retryableError(&url.Error{Op: "Get", URL: "https://connect.garmin.com", Err: io.EOF}))
+	assert.False(t, retryableError(&url.Error{Op: "Get", URL: "https://connect.garmin.com", Err: &net.DNSError{IsNotFound: true}}))
+	assert.False(t, retryableError(errors.New("x509: certificate signed by unknown authority")))
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2021, 5, 1, 8, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, 120*time.Second, parseRetryAfter("120", now))
+	assert.Equal(t, 90*time.Second, parseRetryAfter("Sat, 01 May 2021 08:01:30 GMT", now))
+	assert.Equal(t, time.Duration(0), parseRetryAfter("Sat, 01 May 2021 07:00:00 GMT", now))
+	assert.Equal(t, time.Duration(0), parseRetryAfter("soon", now))
+}
@@ -0,0 +1,152 @@
+// Package webdav is a minimal WebDAV client, enough to store files by path.
+package webdav
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+type Client struct {
+	baseURL    string
+	username   string
+	password   string
+	httpClient *http.Client
+
+	mu sync.Mutex
+	// collections holds the directories known to exist
+	collections map[string]bool
+}
+
+type Option func(c *Client)
+
+func BasicAuth(username string, password string) Option {
+	return func(c *Client) {
+		c.username = username
+		c.password = password
+	}
+}
+
+func HTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// NewClient returns a client storing files under baseURL, e.g. https://dav.example.com/backup.
+func NewClient(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:     strings.TrimSuffix(baseURL, "/"),
+		httpClient:  http.DefaultClient,
+		collections: make(map[string]bool),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+func (c *Client) Exists(ctx context.Context, name string) (bool, error) {
+	resp, err := c.do(ctx, http.MethodHead, name, nil, "")
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	}
+	return false, statusError(http.MethodHead, name, resp)
+}
+
+func (c *Client) Get(ctx context.Context, name string) ([]byte, error) {
+	resp, err := c.do(ctx, http.MethodGet, name, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, statusError(http.MethodGet, name, resp)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+// Put writes data to name, creating its parent directories first.
+func (c *Client) Put(ctx context.Context, name string, data []byte, contentType string) error {
+	if err := c.mkdirAll(ctx, name); err != nil {
+		return err
+	}
+
+	resp, err := c.do(ctx, http.MethodPut, name, data, contentType)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated, http.StatusNoContent:
+		return nil
+	}
+	return statusError(http.MethodPut, name, resp)
+}
+
+func (c *Client) mkdirAll(ctx context.Context, name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	parts := strings.Split(strings.Trim(name, "/"), "/")
+	dir := ""
+	for _, part := range parts[:len(parts)-1] {
+		dir += part + "/"
+		if c.collections[dir] {
+			continue
+		}
+		resp, err := c.do(ctx, "MKCOL", dir, nil, "")
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		// NOTE: 405 means the collection already exists
+		if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusMethodNotAllowed {
+			return statusError("MKCOL", dir, resp)
+		}
+		c.collections[dir] = true
+	}
+	return nil
+}
+
+func (c *Client) do(ctx context.Context, method string, name string, data []byte, contentType string) (*http.Response, error) {
+	req, err := http.NewRequest(method, c.url(name), bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.username != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+	return c.httpClient.Do(req)
+}
+
+func (c *Client) url(name string) string {
+	parts := strings.Split(strings.TrimPrefix(name, "/"), "/")
+	for i, part := range parts {
+		parts[i] = url.PathEscape(part)
+	}
+	return c.baseURL + "/" + strings.Join(parts, "/")
+}
+
+func statusError(method string, name string, resp *http.Response) error {
+	return fmt.Errorf("webdav: %s %s: %s", method, name, resp.Status)
+}
@@ -0,0 +1,87 @@
+package webdav
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeServer is an in memory WebDAV server, which like real ones refuses files
+// in missing collections.
+type fakeServer struct {
+	mu          sync.Mutex
+	files       map[string][]byte
+	collections map[string]bool
+}
+
+func (s *fakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if user, password, ok := r.BasicAuth(); !ok || user != "user" || password != "secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	name := r.URL.Path
+	parent := name[:strings.LastIndex(strings.TrimSuffix(name, "/"), "/")+1]
+	switch r.Method {
+	case "MKCOL":
+		if s.collections[name] {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		s.collections[name] = true
+		w.WriteHeader(http.StatusCreated)
+	case http.MethodPut:
+		if !s.collections[parent] {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		data, _ := ioutil.ReadAll(r.Body)
+		s.files[name] = data
+		w.WriteHeader(http.StatusCreated)
+	case http.MethodHead, http.MethodGet:
+		data, ok := s.files[name]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write(data)
+	}
+}
+
+func TestClient(t *testing.T) {
+	fake := &fakeServer{
+		files:       make(map[string][]byte),
+		collections: map[string]bool{"/dav/": true, "/dav/garmin/2021/": true},
+	}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	client := NewClient(server.URL+"/dav/garmin/", BasicAuth("user", "secret"))
+	ctx := context.Background()
+
+	exists, err := client.Exists(ctx, "2021/01/1.json")
+	assert.Nil(t, err)
+	assert.False(t, exists)
+
+	assert.Nil(t, client.Put(ctx, "2021/01/1.json", []byte(`{"activityId":1}`), "application/json"))
+	assert.Nil(t, client.Put(ctx, "2021/01/1 copy.fit", []byte("fit"), "application/octet-stream"))
+	assert.True(t, fake.collections["/dav/garmin/2021/01/"])
+	assert.Equal(t, []byte("fit"), fake.files["/dav/garmin/2021/01/1 copy.fit"])
+
+	exists, err = client.Exists(ctx, "2021/01/1.json")
+	assert.Nil(t, err)
+	assert.True(t, exists)
+	data, err := client.Get(ctx, "2021/01/1.json")
+	assert.Nil(t, err)
+	assert.Equal(t, `{"activityId":1}`, string(data))
+
+	_, err = NewClient(server.URL+"/dav/garmin").Exists(ctx, "2021/01/1.json")
+	assert.NotNil(t, err)
+}